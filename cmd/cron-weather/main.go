@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"cron-weather/internal/app"
 	"cron-weather/internal/config"
@@ -16,7 +17,7 @@ import (
 
 func main() {
 	cfg := config.MustLoad()
-	log := logger.SetupLogger(cfg.Env)
+	log := logger.SetupLogger(cfg.Env, logger.NewRedactor(cfg.Secrets()...))
 
 	log.Info("start cron-weather service",
 		slog.String("version", "0.2.0"))
@@ -31,6 +32,13 @@ func main() {
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
+	defer func() {
+		cctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := tg.Close(cctx); err != nil {
+			log.Error("failed to close telegram bot", slog.Any("err", err))
+		}
+	}()
 
 	repo, err := postgres.New(ctx, cfg.Postgres.DSN())
 	if err != nil {
@@ -39,7 +47,11 @@ func main() {
 	}
 	defer repo.Close()
 
-	a := app.New(log, repo, tg, tg, cfg)
+	a, err := app.New(log, repo, tg, tg, cfg)
+	if err != nil {
+		log.Error("failed to init app", slog.Any("err", err))
+		os.Exit(1)
+	}
 
 	if err := a.Start(ctx); err != nil {
 		log.Error("app stopped with error", slog.Any("err", err))
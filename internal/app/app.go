@@ -3,18 +3,27 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"cron-weather/internal/config"
+	"cron-weather/internal/domain"
+	"cron-weather/internal/i18n"
+	"cron-weather/internal/natural"
+	"cron-weather/internal/outbox"
+	"cron-weather/internal/render"
 	"cron-weather/internal/scheduler"
 	"cron-weather/internal/storage"
 	"cron-weather/internal/task"
 	"cron-weather/internal/task/weather"
 	"cron-weather/internal/transport"
+	plog "cron-weather/pkg/logger"
 )
 
 // App is the main application service that handles Telegram commands and manages schedules.
@@ -22,37 +31,162 @@ type App struct {
 	logger *slog.Logger
 
 	timezone string
+	lang     string
 
 	subs storage.Repo
 
 	consumer transport.Consumer
 	producer transport.Producer
+	// queue is the persistent outbound queue behind producer (nil without storage).
+	queue *outbox.Queue
 
 	sched *scheduler.Engine
+
+	// commands is the command table, see commandTable.
+	commands []command
+
+	geo   *weather.Client
+	chain *weather.Chain
+
+	budget *weather.Budget
+	// adminChatID may see account-wide budget usage in /usage (0 for nobody).
+	adminChatID int64
+	dailyLimit  int
 }
 
 // New constructs the application with storage, transports and runtime scheduler.
-func New(logger *slog.Logger, subs storage.Repo, consumer transport.Consumer, producer transport.Producer, cfg *config.Config) *App {
+func New(logger *slog.Logger, subs storage.Repo, consumer transport.Consumer, producer transport.Producer, cfg *config.Config) (*App, error) {
 	tz := strings.TrimSpace(cfg.Timezone)
 	if tz == "" {
 		tz = "UTC"
 	}
 
-	client := weather.NewOpenWeatherClient(cfg.OpenWeather.APIKey)
-	wt := weather.NewTask(logger, subs, client, cfg.OpenWeather.DailyLimit)
+	lang, ok := i18n.Normalize(cfg.Language)
+	if !ok {
+		lang = i18n.Russian
+	}
+
+	// Error texts stored in runs and outbox must not carry the bot token or API keys.
+	redact := plog.NewRedactor(cfg.Secrets()...).Redact
+
+	// All outgoing messages go through the rate-limited queue when storage is available.
+	// Long messages are split in exactly one place: by the queue, or on the way to the bot without it.
+	var queue *outbox.Queue
+	if subs != nil {
+		queue = outbox.New(logger, subs, producer, outbox.Options{
+			GlobalRate:  cfg.TgBot.RateGlobal,
+			ChatRate:    cfg.TgBot.RateChat,
+			GroupRate:   cfg.TgBot.RateGroupPerMinute / 60,
+			MaxAttempts: cfg.TgBot.SendMaxAttempts,
+			Redact:      redact,
+		})
+		producer = queue
+	} else {
+		producer = transport.NewSplitter(producer)
+	}
+
+	owm := cfg.OpenWeather
+	owmOpts := []weather.Option{
+		weather.WithBaseURL(owm.BaseURL),
+		weather.WithTimeout(owm.Timeout),
+		weather.WithRetry(owm.MaxAttempts, owm.BackoffBase, owm.BackoffMax),
+		weather.WithBodyLimit(owm.BodyLimit),
+	}
+
+	// Geocoding uses OpenWeather whenever a key is configured.
+	var geo *weather.Client
+	if owm.APIKey != "" {
+		geo = weather.NewOpenWeatherClient(owm.APIKey, owmOpts...)
+	}
+
+	// The account budget tracks OpenWeather billing only.
+	var budget *weather.Budget
+	adminChatID := cfg.TgBot.AdminChatID
+	if slices.Contains(cfg.WeatherProvider, weather.ProviderOpenWeather) {
+		budget = weather.NewBudget(logger, subs, weather.BudgetOptions{
+			Daily:           cfg.OpenWeather.BudgetDaily,
+			Monthly:         cfg.OpenWeather.BudgetMonthly,
+			DegradeInterval: cfg.OpenWeather.BudgetDegradeInterval,
+			Language:        lang,
+			Notify: func(ctx context.Context, text string) {
+				if adminChatID == 0 {
+					return
+				}
+				_ = producer.Send(ctx, transport.Message{ChatID: adminChatID, Text: text})
+			},
+		})
+	}
+	providers := make([]weather.Provider, 0, len(cfg.WeatherProvider))
+	for _, name := range cfg.WeatherProvider {
+		p, err := weather.NewProvider(name, owm.APIKey, owmOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("weather provider: %w", err)
+		}
+		if p.Name() == weather.ProviderOpenWeather {
+			p = budget.Wrap(p)
+		}
+		providers = append(providers, p)
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("weather provider: none configured")
+	}
+	provider := providers[0]
+	var chain *weather.Chain
+	if len(providers) > 1 {
+		chain = weather.NewChain(logger, providers, weather.ChainOptions{
+			FailureThreshold: cfg.ProviderFailureThreshold,
+			ProbeInterval:    cfg.ProviderProbeInterval,
+		})
+		provider = chain
+	}
+
+	wt := weather.NewTask(logger, subs, provider, weather.Options{
+		DailyLimit:      cfg.OpenWeather.DailyLimit,
+		UrgentCooldown:  cfg.OpenWeather.UrgentCooldown,
+		UrgentAllClear:  cfg.OpenWeather.UrgentAllClear,
+		DefaultLanguage: lang,
+		CacheTTL:        cfg.OpenWeather.CacheTTL,
+		SharedCache:     cfg.OpenWeather.CacheShared,
+		Budget:          budget,
+	})
 	runners := map[string]task.Runner{
 		"weather": wt,
 		"cron":    wt,
+		"nowcast": weather.NewNowcastTask(wt, cfg.OpenWeather.NowcastHorizon),
+	}
+	// Air pollution data is only available from OpenWeather.
+	if geo != nil {
+		runners["air"] = weather.NewAirTask(logger, subs, geo, weather.AirOptions{
+			DailyLimit:      cfg.OpenWeather.DailyLimit,
+			Level:           cfg.OpenWeather.AirLevel,
+			DefaultLanguage: lang,
+		})
 	}
 	sched := scheduler.New(logger, subs, producer, runners, tz)
+	sched.SetRedact(redact)
+	bypass, ok := domain.ParseSeverity(cfg.QuietBypassSeverity)
+	if !ok {
+		return nil, fmt.Errorf("quiet hours: unknown bypass severity %q", cfg.QuietBypassSeverity)
+	}
+	sched.SetQuietBypass(bypass)
+	sched.SetMergeMessages(cfg.TgBot.MergeMessages)
 	return &App{
 		logger:   logger,
 		timezone: tz,
+		lang:     lang,
 		subs:     subs,
 		consumer: consumer,
 		producer: producer,
+		queue:    queue,
 		sched:    sched,
-	}
+		commands: commandTable(),
+		geo:      geo,
+		chain:    chain,
+
+		budget:      budget,
+		adminChatID: adminChatID,
+		dailyLimit:  cfg.OpenWeather.DailyLimit,
+	}, nil
 }
 
 // Start runs the application main loop and blocks until context is cancelled.
@@ -65,6 +199,18 @@ func (a *App) Start(ctx context.Context) error {
 		defer a.sched.Stop(context.Background())
 	}
 
+	// Deliver queued messages.
+	if a.queue != nil {
+		go a.queue.Run(ctx)
+	}
+
+	// Probe failed weather providers to close their circuit breakers.
+	if a.chain != nil {
+		go a.chain.Probe(ctx)
+	}
+
+	a.registerCommands(ctx)
+
 	jobs, err := a.consumer.Get(ctx)
 	if err != nil {
 		return fmt.Errorf("consumer get: %w", err)
@@ -84,21 +230,15 @@ func (a *App) Start(ctx context.Context) error {
 }
 
 func (a *App) handle(ctx context.Context, job transport.CronJob) {
-	switch job.Command {
-	case "start_scheduler":
-		a.cmdStartScheduler(ctx, job.ChatID)
-	case "stop_scheduler":
-		a.cmdStopScheduler(ctx, job.ChatID)
-	case "list_scheduler":
-		a.cmdListScheduler(ctx, job.ChatID)
-	case "set_location":
-		a.cmdSetLocation(ctx, job.ChatID, job.Args)
-	case "start":
-		a.cmdStartCron(ctx, job.ChatID, job.Args)
-	case "stop":
-		a.cmdStopCron(ctx, job.ChatID, job.Args)
-	default:
+	if job.Callback != nil {
+		a.handleCallback(ctx, job)
+		return
+	}
+	if job.Command == "" {
+		a.handleText(ctx, job.ChatID, job.Args)
+		return
 	}
+	a.dispatch(ctx, job)
 }
 
 func (a *App) cmdStartScheduler(ctx context.Context, chatID int64) {
@@ -108,10 +248,7 @@ func (a *App) cmdStartScheduler(ctx context.Context, chatID int64) {
 		}
 	}
 
-	_ = a.producer.Send(ctx, transport.Message{
-		ChatID: chatID,
-		Text:   "scheduler successfully added. /help_scheduler return available commands",
-	})
+	a.reply(ctx, chatID, "subscription.started")
 }
 
 func (a *App) cmdStopScheduler(ctx context.Context, chatID int64) {
@@ -128,89 +265,84 @@ func (a *App) cmdStopScheduler(ctx context.Context, chatID int64) {
 	if a.subs != nil {
 		if err := a.subs.DeactivateSubscription(ctx, chatID); err != nil {
 			a.logger.Error("failed to deactivate subscription", slog.Any("err", err), slog.Int64("chat_id", chatID))
-			_ = a.producer.Send(ctx, transport.Message{
-				ChatID: chatID,
-				Text:   "failed to stop scheduler",
-			})
+			a.reply(ctx, chatID, "subscription.stop_failed")
 			return
 		}
 	}
-	_ = a.producer.Send(ctx, transport.Message{
-		ChatID: chatID,
-		Text:   "scheduler stopped",
-	})
+	a.reply(ctx, chatID, "subscription.stopped")
 }
 
 func (a *App) cmdListScheduler(ctx context.Context, chatID int64) {
 	if a.subs == nil {
-		_ = a.producer.Send(ctx, transport.Message{
-			ChatID: chatID,
-			Text:   "no storage configured",
-		})
+		a.reply(ctx, chatID, "no_storage")
 		return
 	}
 
-	items, err := a.subs.ListActiveSchedulers(ctx, chatID)
-	if err != nil {
-		a.logger.Error("failed to list schedulers", slog.Any("err", err), slog.Int64("chat_id", chatID))
-		_ = a.producer.Send(ctx, transport.Message{
-			ChatID: chatID,
-			Text:   "failed to list schedulers",
-		})
+	msg, key := a.scheduleList(ctx, chatID, a.language(ctx, chatID))
+	if key != "" {
+		a.reply(ctx, chatID, key)
 		return
 	}
-	if len(items) == 0 {
-		_ = a.producer.Send(ctx, transport.Message{
-			ChatID: chatID,
-			Text:   "no active schedulers",
-		})
-		return
-	}
-
-	var b strings.Builder
-	b.WriteString("active schedulers:\n")
-	for _, it := range items {
-		b.WriteString("- id: ")
-		b.WriteString(it.ID)
-		b.WriteString(" | expr: ")
-		b.WriteString(it.Expr)
-		b.WriteString(" | start_at: ")
-		b.WriteString(formatTime(it.StartAt))
-		b.WriteString(" | end_at: ")
-		b.WriteString(formatTime(it.EndAt))
-		b.WriteString("\n")
-	}
-	_ = a.producer.Send(ctx, transport.Message{
-		ChatID: chatID,
-		Text:   b.String(),
-	})
+	_ = a.producer.Send(ctx, msg)
 }
 
 func (a *App) cmdStartCron(ctx context.Context, chatID int64, argsRaw string) {
 	if a.subs == nil {
-		_ = a.producer.Send(ctx, transport.Message{
-			ChatID: chatID,
-			Text:   "no storage configured",
-		})
+		a.reply(ctx, chatID, "no_storage")
 		return
 	}
 
-	cronExpr, startAt, endAt, err := parseStartArgs(argsRaw)
+	opts, argsRaw := parseOptions(argsRaw)
+	cronExpr, phrase, startAt, endAt, err := parseStartArgs(argsRaw)
+	if errors.Is(err, errInvalidSchedule) {
+		a.reply(ctx, chatID, "start.invalid_schedule", phrase)
+		return
+	}
 	if err != nil {
-		_ = a.producer.Send(ctx, transport.Message{
-			ChatID: chatID,
-			Text:   "usage: /start <cron expr> <start_at|-> <end_at|-> (times RFC3339)",
-		})
+		a.reply(ctx, chatID, "start.usage")
 		return
 	}
 
-	id, err := a.subs.CreateScheduler(ctx, chatID, cronExpr, a.timezone, startAt, endAt)
+	s := domain.Scheduler{
+		Expr:    cronExpr,
+		TZ:      a.timezone,
+		StartAt: startAt,
+		EndAt:   endAt,
+	}
+	for k, v := range opts {
+		switch k {
+		case "location":
+			loc, err := a.subs.GetLocation(ctx, chatID, v)
+			if errors.Is(err, storage.ErrNotFound) {
+				a.reply(ctx, chatID, "locations.not_found", v)
+				return
+			}
+			if err != nil {
+				a.logger.Error("failed to load location", slog.Any("err", err), slog.Int64("chat_id", chatID))
+				a.reply(ctx, chatID, "start.failed")
+				return
+			}
+			s.LocationID = loc.ID
+		case "kind":
+			if a.sched == nil || !a.sched.HasKind(v) {
+				a.reply(ctx, chatID, "start.unknown_kind", v)
+				return
+			}
+			s.Kind = v
+		default:
+			a.reply(ctx, chatID, "start.usage")
+			return
+		}
+	}
+
+	if phrase != "" {
+		a.reply(ctx, chatID, "start.interpreted", phrase, cronExpr)
+	}
+
+	id, err := a.subs.CreateScheduler(ctx, chatID, s)
 	if err != nil {
 		a.logger.Error("failed to create scheduler", slog.Any("err", err), slog.Int64("chat_id", chatID))
-		_ = a.producer.Send(ctx, transport.Message{
-			ChatID: chatID,
-			Text:   "failed to create scheduler",
-		})
+		a.reply(ctx, chatID, "start.failed")
 		return
 	}
 
@@ -218,14 +350,13 @@ func (a *App) cmdStartCron(ctx context.Context, chatID int64, argsRaw string) {
 		slog.String("scheduler_id", id),
 		slog.Int64("chat_id", chatID),
 		slog.String("cron_expr", cronExpr),
+		slog.String("location_id", s.LocationID),
+		slog.String("kind", s.Kind),
 		slog.String("start_at", formatTime(startAt)),
 		slog.String("end_at", formatTime(endAt)),
 	)
 
-	_ = a.producer.Send(ctx, transport.Message{
-		ChatID: chatID,
-		Text:   fmt.Sprintf("scheduler created: %s", id),
-	})
+	a.reply(ctx, chatID, "start.created", id)
 
 	// Register in runtime cron.
 	if a.sched != nil {
@@ -237,19 +368,13 @@ func (a *App) cmdStartCron(ctx context.Context, chatID int64, argsRaw string) {
 
 func (a *App) cmdStopCron(ctx context.Context, chatID int64, argsRaw string) {
 	if a.subs == nil {
-		_ = a.producer.Send(ctx, transport.Message{
-			ChatID: chatID,
-			Text:   "no storage configured",
-		})
+		a.reply(ctx, chatID, "no_storage")
 		return
 	}
 
 	id := strings.TrimSpace(argsRaw)
 	if id == "" {
-		_ = a.producer.Send(ctx, transport.Message{
-			ChatID: chatID,
-			Text:   "usage: /stop <scheduler_id>",
-		})
+		a.reply(ctx, chatID, "stop.usage")
 		return
 	}
 
@@ -259,10 +384,7 @@ func (a *App) cmdStopCron(ctx context.Context, chatID int64, argsRaw string) {
 			slog.Int64("chat_id", chatID),
 			slog.String("scheduler_id", id),
 		)
-		_ = a.producer.Send(ctx, transport.Message{
-			ChatID: chatID,
-			Text:   "failed to stop scheduler",
-		})
+		a.reply(ctx, chatID, "stop.failed")
 		return
 	}
 	if a.sched != nil {
@@ -274,13 +396,48 @@ func (a *App) cmdStopCron(ctx context.Context, chatID int64, argsRaw string) {
 		slog.Int64("chat_id", chatID),
 	)
 
-	_ = a.producer.Send(ctx, transport.Message{
-		ChatID: chatID,
-		Text:   "scheduler stopped",
-	})
+	a.reply(ctx, chatID, "stop.done")
+}
+
+// parseOptions strips leading key=value tokens (e.g. "location=dacha") from args.
+// Cron expressions never contain '=', so the first token without it ends the options.
+func parseOptions(argsRaw string) (opts map[string]string, rest string) {
+	opts = map[string]string{}
+	rest = argsRaw
+	for {
+		word, tail := cutWord(rest)
+		k, v, ok := strings.Cut(word, "=")
+		if !ok || k == "" {
+			return opts, rest
+		}
+		opts[strings.ToLower(k)] = v
+		rest = tail
+	}
 }
 
-func parseStartArgs(argsRaw string) (cronExpr string, startAt *time.Time, endAt *time.Time, err error) {
+// errInvalidSchedule is returned by parseStartArgs when the schedule is neither a cron
+// expression nor a phrase the natural parser understands.
+var errInvalidSchedule = errors.New("invalid schedule")
+
+// parseStartArgs splits /start arguments into the schedule and its time window.
+// A schedule that is not a valid cron expression is parsed as a natural-language phrase;
+// phrase is then the original text and cronExpr its translation.
+func parseStartArgs(argsRaw string) (cronExpr, phrase string, startAt, endAt *time.Time, err error) {
+	cronExpr, startAt, endAt, err = splitStartArgs(argsRaw)
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+	if scheduler.ValidateSpec(cronExpr) == nil {
+		return cronExpr, "", startAt, endAt, nil
+	}
+	s, err := natural.Parse(cronExpr)
+	if err != nil {
+		return "", cronExpr, nil, nil, errInvalidSchedule
+	}
+	return s.Expr, cronExpr, startAt, endAt, nil
+}
+
+func splitStartArgs(argsRaw string) (cronExpr string, startAt *time.Time, endAt *time.Time, err error) {
 	fields := strings.Fields(strings.TrimSpace(argsRaw))
 	if len(fields) == 0 {
 		return "", nil, nil, fmt.Errorf("empty args")
@@ -362,20 +519,18 @@ func formatTime(t *time.Time) string {
 
 func (a *App) cmdSetLocation(ctx context.Context, chatID int64, argsRaw string) {
 	if a.subs == nil {
-		_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: "no storage configured"})
+		a.reply(ctx, chatID, "no_storage")
 		return
 	}
 
-	parts := strings.Fields(strings.TrimSpace(argsRaw))
-	if len(parts) != 2 {
-		_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: "usage: /set_location <lat> <lon>"})
+	query := strings.TrimSpace(argsRaw)
+	if query == "" {
+		a.reply(ctx, chatID, "location.usage")
 		return
 	}
 
-	lat, err1 := strconv.ParseFloat(parts[0], 64)
-	lon, err2 := strconv.ParseFloat(parts[1], 64)
-	if err1 != nil || err2 != nil {
-		_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: "invalid coordinates; usage: /set_location <lat> <lon>"})
+	lat, lon, label, ok := a.resolveCoordinates(ctx, chatID, query, "/set_location")
+	if !ok {
 		return
 	}
 
@@ -386,9 +541,489 @@ func (a *App) cmdSetLocation(ctx context.Context, chatID int64, argsRaw string)
 
 	if err := a.subs.SetSubscriptionLocation(ctx, chatID, lat, lon); err != nil {
 		a.logger.Error("failed to set location", slog.Any("err", err), slog.Int64("chat_id", chatID))
-		_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: "failed to set location"})
+		a.reply(ctx, chatID, "location.failed")
+		return
+	}
+
+	if label != "" {
+		a.reply(ctx, chatID, "location.set_place", label, lat, lon)
+		return
+	}
+	a.reply(ctx, chatID, "location.set", lat, lon)
+}
+
+// resolveCoordinates parses "<lat> <lon>" or geocodes a city name and validates the result.
+// On failure it replies to the chat; cmd prefixes the suggested commands of a disambiguation list.
+func (a *App) resolveCoordinates(ctx context.Context, chatID int64, query, cmd string) (lat, lon float64, label string, ok bool) {
+	parts := strings.Fields(query)
+	if _, err := strconv.ParseFloat(parts[0], 64); err == nil {
+		// Raw coordinates: <lat> <lon>.
+		if len(parts) != 2 {
+			a.reply(ctx, chatID, "location.usage")
+			return 0, 0, "", false
+		}
+		var err1, err2 error
+		lat, err1 = strconv.ParseFloat(parts[0], 64)
+		lon, err2 = strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			a.reply(ctx, chatID, "location.invalid")
+			return 0, 0, "", false
+		}
+	} else {
+		// City name: resolve via geocoding.
+		place, found := a.geocode(ctx, chatID, query, cmd)
+		if !found {
+			return 0, 0, "", false
+		}
+		lat, lon, label = place.Lat, place.Lon, place.Label()
+	}
+
+	if err := domain.ValidateCoordinates(lat, lon); err != nil {
+		a.reply(ctx, chatID, "location.out_of_range")
+		return 0, 0, "", false
+	}
+	return lat, lon, label, true
+}
+
+// geocode resolves a city name to a single place. When several places match, it replies
+// with a disambiguation list of ready-to-use cmd commands and returns false.
+func (a *App) geocode(ctx context.Context, chatID int64, query, cmd string) (weather.Place, bool) {
+	if a.geo == nil {
+		a.reply(ctx, chatID, "location.usage")
+		return weather.Place{}, false
+	}
+
+	lang := a.language(ctx, chatID)
+	places, err := a.geo.Geocode(ctx, query, 5, lang)
+	if err != nil {
+		a.logger.Error("failed to geocode location", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		a.reply(ctx, chatID, "location.geocode_failed")
+		return weather.Place{}, false
+	}
+
+	switch len(places) {
+	case 0:
+		a.reply(ctx, chatID, "location.not_found", query)
+		return weather.Place{}, false
+	case 1:
+		return places[0], true
+	}
+
+	var b strings.Builder
+	b.WriteString(i18n.T(lang, "location.choose"))
+	for i, p := range places {
+		b.WriteString("\n")
+		b.WriteString(i18n.T(lang, "location.option", i+1, p.Label(), cmd, formatCoord(p.Lat), formatCoord(p.Lon)))
+	}
+	_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: b.String()})
+	return weather.Place{}, false
+}
+
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+func (a *App) cmdLocation(ctx context.Context, chatID int64, argsRaw string) {
+	if a.subs == nil {
+		a.reply(ctx, chatID, "no_storage")
+		return
+	}
+
+	action, rest := cutWord(argsRaw)
+	switch action {
+	case "add":
+		name, query := cutWord(rest)
+		query = strings.TrimSpace(query)
+		if name == "" || query == "" {
+			a.reply(ctx, chatID, "locations.usage")
+			return
+		}
+		lat, lon, _, ok := a.resolveCoordinates(ctx, chatID, query, "/location add "+name)
+		if !ok {
+			return
+		}
+		if _, err := a.subs.ActiveSubscription(ctx, chatID); err != nil {
+			a.logger.Error("failed to ensure subscription", slog.Any("err", err))
+		}
+		if _, err := a.subs.AddLocation(ctx, chatID, name, lat, lon); err != nil {
+			a.logger.Error("failed to add location", slog.Any("err", err), slog.Int64("chat_id", chatID))
+			a.reply(ctx, chatID, "locations.add_failed")
+			return
+		}
+		a.reply(ctx, chatID, "locations.added", name, lat, lon)
+
+	case "list":
+		locs, err := a.subs.ListLocations(ctx, chatID)
+		if err != nil {
+			a.logger.Error("failed to list locations", slog.Any("err", err), slog.Int64("chat_id", chatID))
+			a.reply(ctx, chatID, "locations.list_failed")
+			return
+		}
+		if len(locs) == 0 {
+			a.reply(ctx, chatID, "locations.empty")
+			return
+		}
+		lang := a.language(ctx, chatID)
+		var b strings.Builder
+		b.WriteString(i18n.T(lang, "locations.header"))
+		for _, l := range locs {
+			b.WriteString("\n")
+			b.WriteString(i18n.T(lang, "locations.item", l.Name, formatCoord(l.Lat), formatCoord(l.Lon)))
+		}
+		_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: b.String()})
+
+	case "remove":
+		name, _ := cutWord(rest)
+		if name == "" {
+			a.reply(ctx, chatID, "locations.usage")
+			return
+		}
+		err := a.subs.RemoveLocation(ctx, chatID, name)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			a.reply(ctx, chatID, "locations.not_found", name)
+		case errors.Is(err, storage.ErrInUse):
+			a.reply(ctx, chatID, "locations.in_use", name)
+		case err != nil:
+			a.logger.Error("failed to remove location", slog.Any("err", err), slog.Int64("chat_id", chatID))
+			a.reply(ctx, chatID, "locations.remove_failed")
+		default:
+			a.reply(ctx, chatID, "locations.removed", name)
+		}
+
+	default:
+		a.reply(ctx, chatID, "locations.usage")
+	}
+}
+
+func (a *App) cmdTemplate(ctx context.Context, chatID int64, argsRaw string) {
+	if a.subs == nil {
+		a.reply(ctx, chatID, "no_storage")
+		return
+	}
+
+	action, rest := cutWord(argsRaw)
+	switch action {
+	case "":
+		lang := a.language(ctx, chatID)
+		var b strings.Builder
+		b.WriteString(i18n.T(lang, "template.header"))
+		b.WriteString("\n")
+		for _, name := range render.Names() {
+			b.WriteString("- ")
+			b.WriteString(name)
+			b.WriteString("\n")
+		}
+		b.WriteString(i18n.T(lang, "template.usage"))
+		_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: b.String()})
+
+	case "show":
+		name, _ := cutWord(rest)
+		body, ok := render.Default(name)
+		if !ok {
+			a.reply(ctx, chatID, "template.usage")
+			return
+		}
+		subID, err := a.subs.ActiveSubscription(ctx, chatID)
+		if err != nil {
+			a.logger.Error("failed to ensure subscription", slog.Any("err", err))
+		} else if overrides, err := a.subs.ListTemplates(ctx, subID); err == nil && overrides[name] != "" {
+			body = overrides[name]
+		}
+		_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: body})
+
+	case "set":
+		name, body := cutWord(rest)
+		if name == "" || strings.TrimSpace(body) == "" {
+			a.reply(ctx, chatID, "template.usage")
+			return
+		}
+		if err := render.Validate(name, body); err != nil {
+			a.reply(ctx, chatID, "template.invalid", err)
+			return
+		}
+		if _, err := a.subs.ActiveSubscription(ctx, chatID); err != nil {
+			a.logger.Error("failed to ensure subscription", slog.Any("err", err))
+		}
+		if err := a.subs.SetTemplate(ctx, chatID, name, body); err != nil {
+			a.logger.Error("failed to set template", slog.Any("err", err), slog.Int64("chat_id", chatID))
+			a.reply(ctx, chatID, "template.set_failed")
+			return
+		}
+		a.reply(ctx, chatID, "template.updated", name)
+
+	case "reset":
+		name, _ := cutWord(rest)
+		if _, ok := render.Default(name); !ok {
+			a.reply(ctx, chatID, "template.usage")
+			return
+		}
+		if err := a.subs.ResetTemplate(ctx, chatID, name); err != nil {
+			a.logger.Error("failed to reset template", slog.Any("err", err), slog.Int64("chat_id", chatID))
+			a.reply(ctx, chatID, "template.reset_failed")
+			return
+		}
+		a.reply(ctx, chatID, "template.reset", name)
+
+	case "mode":
+		modeArg, _ := cutWord(rest)
+		var mode string
+		switch strings.ToLower(modeArg) {
+		case "html":
+			mode = transport.ParseModeHTML
+		case "markdown", "markdownv2":
+			mode = transport.ParseModeMarkdownV2
+		case "plain":
+			mode = ""
+		default:
+			a.reply(ctx, chatID, "template.usage")
+			return
+		}
+		if _, err := a.subs.ActiveSubscription(ctx, chatID); err != nil {
+			a.logger.Error("failed to ensure subscription", slog.Any("err", err))
+		}
+		if err := a.subs.SetParseMode(ctx, chatID, mode); err != nil {
+			a.logger.Error("failed to set parse mode", slog.Any("err", err), slog.Int64("chat_id", chatID))
+			a.reply(ctx, chatID, "template.mode_failed")
+			return
+		}
+		a.reply(ctx, chatID, "template.mode_set", strings.ToLower(modeArg))
+
+	default:
+		a.reply(ctx, chatID, "template.usage")
+	}
+}
+
+// cutWord splits s into the first whitespace-separated word and the remainder.
+// The remainder keeps its inner formatting (newlines), only leading whitespace is trimmed.
+func cutWord(s string) (word, rest string) {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimLeftFunc(s[i:], unicode.IsSpace)
+}
+
+func (a *App) cmdLanguage(ctx context.Context, chatID int64, argsRaw string) {
+	if a.subs == nil {
+		a.reply(ctx, chatID, "no_storage")
 		return
 	}
 
-	_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: fmt.Sprintf("location set: lat=%v lon=%v", lat, lon)})
+	lang, ok := i18n.Normalize(argsRaw)
+	if !ok {
+		a.reply(ctx, chatID, "language.usage")
+		return
+	}
+
+	// Ensure subscription exists.
+	if _, err := a.subs.ActiveSubscription(ctx, chatID); err != nil {
+		a.logger.Error("failed to ensure subscription", slog.Any("err", err))
+	}
+
+	if err := a.subs.SetSubscriptionLanguage(ctx, chatID, lang); err != nil {
+		a.logger.Error("failed to set language", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		a.reply(ctx, chatID, "language.failed")
+		return
+	}
+
+	a.reply(ctx, chatID, "language.set")
+}
+
+func (a *App) cmdUnits(ctx context.Context, chatID int64, argsRaw string) {
+	if a.subs == nil {
+		a.reply(ctx, chatID, "no_storage")
+		return
+	}
+
+	units := strings.ToLower(strings.TrimSpace(argsRaw))
+	switch units {
+	case domain.UnitsMetric, domain.UnitsImperial, domain.UnitsStandard:
+	default:
+		a.reply(ctx, chatID, "units.usage")
+		return
+	}
+
+	// Ensure subscription exists.
+	if _, err := a.subs.ActiveSubscription(ctx, chatID); err != nil {
+		a.logger.Error("failed to ensure subscription", slog.Any("err", err))
+	}
+
+	if err := a.subs.SetSubscriptionUnits(ctx, chatID, units); err != nil {
+		a.logger.Error("failed to set units", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		a.reply(ctx, chatID, "units.failed")
+		return
+	}
+
+	a.reply(ctx, chatID, "units.set", units)
+}
+
+// cmdQuiet shows, sets (/quiet 22:00-07:00 [tz]) or disables (/quiet off) quiet hours.
+func (a *App) cmdQuiet(ctx context.Context, chatID int64, argsRaw string) {
+	if a.subs == nil {
+		a.reply(ctx, chatID, "no_storage")
+		return
+	}
+
+	// Ensure subscription exists.
+	if _, err := a.subs.ActiveSubscription(ctx, chatID); err != nil {
+		a.logger.Error("failed to ensure subscription", slog.Any("err", err))
+	}
+
+	window, tz := cutWord(argsRaw)
+	tz = strings.TrimSpace(tz)
+	switch strings.ToLower(window) {
+	case "":
+		sub, err := a.subs.GetSubscription(ctx, chatID)
+		if err != nil {
+			a.logger.Error("failed to load subscription", slog.Any("err", err), slog.Int64("chat_id", chatID))
+			a.reply(ctx, chatID, "quiet.failed")
+			return
+		}
+		if sub.Quiet == nil {
+			a.reply(ctx, chatID, "quiet.off")
+			return
+		}
+		if sub.TZ == "" {
+			sub.TZ = a.timezone
+		}
+		a.reply(ctx, chatID, "quiet.current", sub.Quiet.String(), sub.TZ)
+		return
+	case "off":
+		if err := a.subs.SetQuietHours(ctx, chatID, nil, ""); err != nil {
+			a.logger.Error("failed to disable quiet hours", slog.Any("err", err), slog.Int64("chat_id", chatID))
+			a.reply(ctx, chatID, "quiet.failed")
+			return
+		}
+		a.reply(ctx, chatID, "quiet.off")
+		return
+	}
+
+	q, err := domain.ParseQuietHours(window)
+	if err != nil {
+		a.reply(ctx, chatID, "quiet.usage")
+		return
+	}
+	if tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			a.reply(ctx, chatID, "quiet.bad_tz", tz)
+			return
+		}
+	}
+	if err := a.subs.SetQuietHours(ctx, chatID, &q, tz); err != nil {
+		a.logger.Error("failed to set quiet hours", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		a.reply(ctx, chatID, "quiet.failed")
+		return
+	}
+	a.reply(ctx, chatID, "quiet.set", q.String())
+}
+
+// cmdMinSeverity shows or sets the minimum severity of delivered weather alerts.
+func (a *App) cmdMinSeverity(ctx context.Context, chatID int64, argsRaw string) {
+	if a.subs == nil {
+		a.reply(ctx, chatID, "no_storage")
+		return
+	}
+
+	// Ensure subscription exists.
+	if _, err := a.subs.ActiveSubscription(ctx, chatID); err != nil {
+		a.logger.Error("failed to ensure subscription", slog.Any("err", err))
+	}
+
+	lang := a.language(ctx, chatID)
+	if strings.TrimSpace(argsRaw) == "" {
+		sub, err := a.subs.GetSubscription(ctx, chatID)
+		if err != nil {
+			a.logger.Error("failed to load subscription", slog.Any("err", err), slog.Int64("chat_id", chatID))
+			a.reply(ctx, chatID, "min_severity.failed")
+			return
+		}
+		a.reply(ctx, chatID, "min_severity.current", i18n.T(lang, "severity."+sub.MinSeverity.String()))
+		return
+	}
+
+	severity, ok := domain.ParseSeverity(argsRaw)
+	if !ok {
+		a.reply(ctx, chatID, "min_severity.usage")
+		return
+	}
+	if err := a.subs.SetMinSeverity(ctx, chatID, severity); err != nil {
+		a.logger.Error("failed to set min severity", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		a.reply(ctx, chatID, "min_severity.failed")
+		return
+	}
+	a.reply(ctx, chatID, "min_severity.set", i18n.T(lang, "severity."+severity.String()))
+}
+
+func (a *App) cmdUsage(ctx context.Context, chatID int64) {
+	if a.subs == nil {
+		a.reply(ctx, chatID, "no_storage")
+		return
+	}
+
+	chatUsed, err := a.subs.GetDailyUsage(ctx, chatID, time.Now())
+	if err != nil {
+		a.logger.Error("failed to load daily usage", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		a.reply(ctx, chatID, "usage.failed")
+		return
+	}
+
+	lang := a.language(ctx, chatID)
+	var b strings.Builder
+	b.WriteString(i18n.T(lang, "usage.header"))
+	// Account-wide numbers are shown to the admin chat only.
+	admin := a.adminChatID != 0 && chatID == a.adminChatID
+	if admin && a.budget != nil {
+		u, err := a.budget.Usage(ctx)
+		if err != nil {
+			a.logger.Error("failed to load budget usage", slog.Any("err", err))
+			a.reply(ctx, chatID, "usage.failed")
+			return
+		}
+		b.WriteString("\n")
+		b.WriteString(i18n.T(lang, "usage.day", u.Day, u.DayUsed, formatLimit(u.DayLimit)))
+		b.WriteString("\n")
+		b.WriteString(i18n.T(lang, "usage.month", u.Month, u.MonthUsed, formatLimit(u.MonthLimit)))
+	}
+	b.WriteString("\n")
+	b.WriteString(i18n.T(lang, "usage.chat", chatUsed, formatLimit(a.dailyLimit)))
+	if admin && a.budget.Degraded(ctx) {
+		b.WriteString("\n")
+		b.WriteString(i18n.T(lang, "usage.degraded", a.budget.DegradeInterval()))
+	}
+	_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: b.String()})
+}
+
+func formatLimit(limit int) string {
+	if limit <= 0 {
+		return "∞"
+	}
+	return strconv.Itoa(limit)
+}
+
+// reply sends a catalog message to the chat in the subscription language.
+func (a *App) reply(ctx context.Context, chatID int64, key string, args ...any) {
+	_ = a.producer.Send(ctx, transport.Message{
+		ChatID: chatID,
+		Text:   i18n.T(a.language(ctx, chatID), key, args...),
+	})
+}
+
+// language returns the chat subscription language or the service default.
+func (a *App) language(ctx context.Context, chatID int64) string {
+	if a.subs == nil {
+		return a.lang
+	}
+	sub, err := a.subs.GetSubscription(ctx, chatID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			a.logger.Warn("failed to load subscription language", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		}
+		return a.lang
+	}
+	if sub.Language == "" {
+		return a.lang
+	}
+	return sub.Language
 }
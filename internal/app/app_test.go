@@ -0,0 +1,185 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/scheduler"
+	"cron-weather/internal/storage"
+	"cron-weather/internal/task"
+	"cron-weather/internal/transport"
+)
+
+// memRepo keeps the subscription, wizards and schedules of chats in memory.
+// Methods the tests do not use panic through the nil embedded Repo.
+type memRepo struct {
+	storage.Repo
+
+	mu sync.Mutex
+	// sub is returned for every chat; nil means no subscription.
+	sub        *domain.Subscription
+	locations  []domain.Location
+	wizards    map[int64]domain.Wizard
+	schedulers map[int64][]domain.Scheduler
+	runs       []domain.Run
+	// ran receives the id of every schedule run recorded by the engine.
+	ran chan string
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{wizards: map[int64]domain.Wizard{}, schedulers: map[int64][]domain.Scheduler{}, ran: make(chan string, 1)}
+}
+
+func (r *memRepo) GetSubscription(context.Context, int64) (domain.Subscription, error) {
+	if r.sub == nil {
+		return domain.Subscription{}, storage.ErrNotFound
+	}
+	return *r.sub, nil
+}
+
+func (r *memRepo) ListLocations(context.Context, int64) ([]domain.Location, error) {
+	return r.locations, nil
+}
+
+func (r *memRepo) GetWizard(_ context.Context, chatID int64) (domain.Wizard, error) {
+	w, ok := r.wizards[chatID]
+	if !ok {
+		return domain.Wizard{}, storage.ErrNotFound
+	}
+	return w, nil
+}
+
+func (r *memRepo) SaveWizard(_ context.Context, w domain.Wizard) error {
+	w.UpdatedAt = time.Now()
+	r.wizards[w.ChatID] = w
+	return nil
+}
+
+func (r *memRepo) DeleteWizard(_ context.Context, chatID int64) error {
+	delete(r.wizards, chatID)
+	return nil
+}
+
+func (r *memRepo) CreateScheduler(_ context.Context, chatID int64, s domain.Scheduler) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.ID = fmt.Sprintf("s%d", len(r.schedulers[chatID])+1)
+	r.schedulers[chatID] = append(r.schedulers[chatID], s)
+	return s.ID, nil
+}
+
+func (r *memRepo) ListActiveSchedulers(_ context.Context, chatID int64) ([]domain.Scheduler, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.schedulers[chatID]), nil
+}
+
+func (r *memRepo) SetSchedulerPaused(_ context.Context, chatID int64, id string, paused bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, s := range r.schedulers[chatID] {
+		if s.ID == id {
+			r.schedulers[chatID][i].Paused = paused
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
+
+func (r *memRepo) StopScheduler(_ context.Context, chatID int64, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schedulers[chatID] = slices.DeleteFunc(r.schedulers[chatID], func(s domain.Scheduler) bool { return s.ID == id })
+	return nil
+}
+
+func (r *memRepo) ListRuns(context.Context, int64, string, int) ([]domain.Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.runs), nil
+}
+
+func (r *memRepo) GetActiveScheduler(_ context.Context, id string) (domain.SchedulerWithTarget, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, items := range r.schedulers {
+		for _, s := range items {
+			if s.ID == id {
+				return domain.SchedulerWithTarget{Scheduler: s, Target: domain.SchedulerTarget{Kind: "telegram", Address: "1"}}, nil
+			}
+		}
+	}
+	return domain.SchedulerWithTarget{}, storage.ErrNotFound
+}
+
+func (r *memRepo) InsertRun(_ context.Context, _, schedulerID string, _ time.Time, _, _, _ string) (int64, error) {
+	r.ran <- schedulerID
+	return 1, nil
+}
+
+func (r *memRepo) UpdateSchedulerNextRunAt(context.Context, string, *time.Time) error { return nil }
+
+// recordingProducer records the messages it is asked to send.
+type recordingProducer struct {
+	mu   sync.Mutex
+	msgs []transport.Message
+}
+
+func (p *recordingProducer) Send(_ context.Context, msg transport.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.msgs = append(p.msgs, msg)
+	return nil
+}
+
+// last returns the latest message sent.
+func (p *recordingProducer) last(t *testing.T) transport.Message {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.msgs) == 0 {
+		t.Fatal("nothing sent")
+	}
+	return p.msgs[len(p.msgs)-1]
+}
+
+// idleRunner is a task runner that produces nothing.
+type idleRunner struct{}
+
+func (idleRunner) Run(context.Context, task.Input) (task.Result, error) { return task.Result{}, nil }
+
+// answerer is a consumer that records callback answers.
+type answerer struct{ notices []string }
+
+func (c *answerer) Get(context.Context) (<-chan transport.CronJob, error) { return nil, nil }
+
+func (c *answerer) AnswerCallback(_ context.Context, _, text string) error {
+	c.notices = append(c.notices, text)
+	return nil
+}
+
+// newTestApp returns an English app with in-memory storage and a scheduler
+// running the weather and air kinds.
+func newTestApp(t *testing.T) (*App, *memRepo, *recordingProducer, *answerer) {
+	t.Helper()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo, producer, consumer := newMemRepo(), &recordingProducer{}, &answerer{}
+	a := &App{
+		logger:   log,
+		timezone: "UTC",
+		lang:     "en",
+		subs:     repo,
+		consumer: consumer,
+		producer: producer,
+		sched:    scheduler.New(log, repo, producer, map[string]task.Runner{"weather": idleRunner{}, "air": idleRunner{}}, "UTC"),
+	}
+	a.commands = commandTable()
+	return a, repo, producer, consumer
+}
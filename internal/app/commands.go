@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"cron-weather/internal/i18n"
+	"cron-weather/internal/transport"
+)
+
+// command is one bot command: dispatch, /help and the Telegram command menu are built from it.
+type command struct {
+	name string
+	// usage is the catalog key of the usage line, empty for commands without arguments.
+	usage string
+	// description is the catalog key of the one-line description.
+	description string
+	// args normalizes the raw arguments; false means they are invalid and the usage is sent instead.
+	args func(raw string) (string, bool)
+	run  func(a *App, ctx context.Context, chatID int64, args string)
+}
+
+// commandTable lists the commands in the order /help and the command menu show them.
+func commandTable() []command {
+	return []command{
+		{name: "start_scheduler", description: "cmd.start_scheduler", args: noArgs, run: withoutArgs((*App).cmdStartScheduler)},
+		{name: "stop_scheduler", description: "cmd.stop_scheduler", args: noArgs, run: withoutArgs((*App).cmdStopScheduler)},
+		{name: "new", description: "cmd.new", args: noArgs, run: withoutArgs((*App).cmdNew)},
+		{name: "cancel", description: "cmd.cancel", args: noArgs, run: withoutArgs((*App).cmdCancel)},
+		{name: "start", usage: "start.usage", description: "cmd.start", args: requiredArgs, run: (*App).cmdStartCron},
+		{name: "list_scheduler", description: "cmd.list_scheduler", args: noArgs, run: withoutArgs((*App).cmdListScheduler)},
+		{name: "stop", usage: "stop.usage", description: "cmd.stop", args: requiredArgs, run: (*App).cmdStopCron},
+		{name: "set_location", usage: "location.usage", description: "cmd.set_location", args: requiredArgs, run: (*App).cmdSetLocation},
+		{name: "location", usage: "locations.usage", description: "cmd.location", args: optionalArgs, run: (*App).cmdLocation},
+		{name: "template", usage: "template.usage", description: "cmd.template", args: optionalArgs, run: (*App).cmdTemplate},
+		{name: "language", usage: "language.usage", description: "cmd.language", args: optionalArgs, run: (*App).cmdLanguage},
+		{name: "units", usage: "units.usage", description: "cmd.units", args: optionalArgs, run: (*App).cmdUnits},
+		{name: "quiet", usage: "quiet.usage", description: "cmd.quiet", args: optionalArgs, run: (*App).cmdQuiet},
+		{name: "min_severity", usage: "min_severity.usage", description: "cmd.min_severity", args: optionalArgs, run: (*App).cmdMinSeverity},
+		{name: "usage", description: "cmd.usage", args: noArgs, run: withoutArgs((*App).cmdUsage)},
+		{name: "help", usage: "help.usage", description: "cmd.help", args: optionalArgs, run: (*App).cmdHelp},
+	}
+}
+
+// noArgs ignores anything after the command.
+func noArgs(string) (string, bool) { return "", true }
+
+func optionalArgs(raw string) (string, bool) { return strings.TrimSpace(raw), true }
+
+func requiredArgs(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	return raw, raw != ""
+}
+
+func withoutArgs(run func(a *App, ctx context.Context, chatID int64)) func(*App, context.Context, int64, string) {
+	return func(a *App, ctx context.Context, chatID int64, _ string) { run(a, ctx, chatID) }
+}
+
+// lookup returns the command with the given name.
+func (a *App) lookup(name string) (command, bool) {
+	name = strings.TrimPrefix(strings.ToLower(name), "/")
+	for _, c := range a.commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// dispatch runs a command, answering with its usage when the arguments are invalid
+// and with a hint when the command is unknown.
+func (a *App) dispatch(ctx context.Context, job transport.CronJob) {
+	c, ok := a.lookup(job.Command)
+	if !ok {
+		a.reply(ctx, job.ChatID, "help.unknown", job.Command)
+		return
+	}
+	args, ok := c.args(job.Args)
+	if !ok {
+		a.reply(ctx, job.ChatID, c.usage)
+		return
+	}
+	c.run(a, ctx, job.ChatID, args)
+}
+
+// cmdHelp lists all commands or shows the description and usage of one.
+func (a *App) cmdHelp(ctx context.Context, chatID int64, argsRaw string) {
+	lang := a.language(ctx, chatID)
+	if argsRaw != "" {
+		c, ok := a.lookup(argsRaw)
+		if !ok {
+			a.reply(ctx, chatID, "help.unknown", strings.TrimPrefix(argsRaw, "/"))
+			return
+		}
+		text := i18n.T(lang, "help.item", c.name, i18n.T(lang, c.description))
+		if c.usage != "" {
+			text += "\n" + i18n.T(lang, c.usage)
+		}
+		_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: text})
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(i18n.T(lang, "help.header"))
+	for _, c := range a.commands {
+		b.WriteString("\n")
+		b.WriteString(i18n.T(lang, "help.item", c.name, i18n.T(lang, c.description)))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(i18n.T(lang, "help.footer"))
+	_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: b.String()})
+}
+
+// registerCommands publishes the command menu for every supported language and,
+// as the default for other clients, in the service language.
+func (a *App) registerCommands(ctx context.Context) {
+	setter, ok := a.consumer.(transport.CommandSetter)
+	if !ok {
+		return
+	}
+	for _, lang := range append([]string{""}, i18n.Supported()...) {
+		catalog := lang
+		if catalog == "" {
+			catalog = a.lang
+		}
+		cmds := make([]transport.BotCommand, 0, len(a.commands))
+		for _, c := range a.commands {
+			cmds = append(cmds, transport.BotCommand{Name: c.name, Description: i18n.T(catalog, c.description)})
+		}
+		if err := setter.SetCommands(ctx, lang, cmds); err != nil {
+			a.logger.Warn("failed to register bot commands", slog.Any("err", err), slog.String("lang", lang))
+		}
+	}
+}
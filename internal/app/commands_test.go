@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"cron-weather/internal/i18n"
+	"cron-weather/internal/transport"
+)
+
+func TestDispatchUnknownCommand(t *testing.T) {
+	a, _, producer, _ := newTestApp(t)
+
+	a.dispatch(context.Background(), transport.CronJob{ChatID: 1, Command: "bogus", Args: "x"})
+	if got, want := producer.last(t).Text, i18n.T("en", "help.unknown", "bogus"); got != want {
+		t.Errorf("reply %q, want %q", got, want)
+	}
+}
+
+func TestDispatchMissingArgs(t *testing.T) {
+	a, _, producer, _ := newTestApp(t)
+
+	var checked int
+	for _, c := range a.commands {
+		if _, ok := c.args("  "); ok {
+			continue
+		}
+		checked++
+		if c.usage == "" {
+			t.Errorf("/%s requires arguments but has no usage line", c.name)
+			continue
+		}
+		a.dispatch(context.Background(), transport.CronJob{ChatID: 1, Command: c.name, Args: "  "})
+		if got, want := producer.last(t).Text, i18n.T("en", c.usage); got != want {
+			t.Errorf("/%s without arguments: reply %q, want %q", c.name, got, want)
+		}
+	}
+	if checked == 0 {
+		t.Fatal("no command requires arguments")
+	}
+}
+
+func TestCommandLookup(t *testing.T) {
+	a, _, _, _ := newTestApp(t)
+	for _, name := range []string{"help", "/help", "HELP"} {
+		if c, ok := a.lookup(name); !ok || c.name != "help" {
+			t.Errorf("lookup(%q) = %q, %v", name, c.name, ok)
+		}
+	}
+	if _, ok := a.lookup("/bogus"); ok {
+		t.Error("lookup(/bogus) found a command")
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	a, _, producer, _ := newTestApp(t)
+	ctx := context.Background()
+
+	a.cmdHelp(ctx, 1, "")
+	list := producer.last(t).Text
+	for _, c := range a.commands {
+		if !strings.Contains(list, "/"+c.name+" — ") {
+			t.Errorf("/help does not list /%s", c.name)
+		}
+	}
+
+	a.cmdHelp(ctx, 1, "/stop")
+	if got := producer.last(t).Text; !strings.Contains(got, i18n.T("en", "stop.usage")) {
+		t.Errorf("/help stop = %q, want the usage line", got)
+	}
+
+	a.cmdHelp(ctx, 1, "/bogus")
+	if got, want := producer.last(t).Text, i18n.T("en", "help.unknown", "bogus"); got != want {
+		t.Errorf("/help bogus = %q, want %q", got, want)
+	}
+}
+
+// botCommandName is what Telegram accepts as a command name.
+var botCommandName = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+func TestCommandTable(t *testing.T) {
+	seen := map[string]bool{}
+	for _, c := range commandTable() {
+		if !botCommandName.MatchString(c.name) || seen[c.name] {
+			t.Errorf("command name %q is invalid or repeated", c.name)
+		}
+		seen[c.name] = true
+		if c.args == nil || c.run == nil {
+			t.Errorf("/%s has no args or run function", c.name)
+		}
+
+		// i18n.T falls back to English and then to the key itself; the i18n tests
+		// check that the other catalogs have every English key.
+		if c.description == "" || i18n.T(i18n.English, c.description) == c.description {
+			t.Errorf("/%s: description key %q is not in the catalog", c.name, c.description)
+		}
+		if c.usage != "" && i18n.T(i18n.English, c.usage) == c.usage {
+			t.Errorf("/%s: usage key %q is not in the catalog", c.name, c.usage)
+		}
+		for _, lang := range i18n.Supported() {
+			// Telegram accepts menu descriptions of 1 to 256 characters.
+			if n := len([]rune(i18n.T(lang, c.description))); n > 256 {
+				t.Errorf("/%s: %s description is %d characters", c.name, lang, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/i18n"
+	"cron-weather/internal/render"
+	"cron-weather/internal/transport"
+)
+
+// Schedule buttons send "sch:<action>:<schedule id>" as callback data (Telegram allows 64 bytes).
+const schedulePrefix = "sch:"
+
+// Schedule button actions.
+const (
+	actionPause   = "pause"
+	actionResume  = "resume"
+	actionAskStop = "stopq"
+	actionStop    = "stop"
+	actionRun     = "run"
+	actionHistory = "hist"
+	actionList    = "list"
+)
+
+// historyLimit is how many runs the history view shows.
+const historyLimit = 10
+
+func scheduleData(action, id string) string {
+	return schedulePrefix + action + ":" + id
+}
+
+// scheduleList renders the active schedules of a chat with a row of buttons per schedule.
+// It returns a catalog key instead when there is nothing to show.
+func (a *App) scheduleList(ctx context.Context, chatID int64, lang string) (transport.Message, string) {
+	items, err := a.subs.ListActiveSchedulers(ctx, chatID)
+	if err != nil {
+		a.logger.Error("failed to list schedulers", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		return transport.Message{}, "schedules.list_failed"
+	}
+	if len(items) == 0 {
+		return transport.Message{}, "schedules.empty"
+	}
+
+	names := map[string]string{}
+	if locs, err := a.subs.ListLocations(ctx, chatID); err == nil {
+		for _, l := range locs {
+			names[l.ID] = l.Name
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(i18n.T(lang, "schedules.header"))
+	b.WriteString("\n")
+	keyboard := make([][]transport.Button, 0, len(items))
+	for i, it := range items {
+		n := i + 1
+		loc := "-"
+		if name, ok := names[it.LocationID]; ok {
+			loc = name
+		}
+		b.WriteString(i18n.T(lang, "schedules.item", n, it.ID, loc, it.Expr, formatTime(it.StartAt), formatTime(it.EndAt)))
+		if it.Paused {
+			b.WriteString(i18n.T(lang, "schedules.paused"))
+		}
+		b.WriteString("\n")
+
+		toggle := transport.Button{Text: fmt.Sprintf("⏸ %d", n), Data: scheduleData(actionPause, it.ID)}
+		if it.Paused {
+			toggle = transport.Button{Text: fmt.Sprintf("▶️ %d", n), Data: scheduleData(actionResume, it.ID)}
+		}
+		keyboard = append(keyboard, []transport.Button{
+			toggle,
+			{Text: fmt.Sprintf("⏹ %d", n), Data: scheduleData(actionAskStop, it.ID)},
+			{Text: fmt.Sprintf("⚡ %d", n), Data: scheduleData(actionRun, it.ID)},
+			{Text: fmt.Sprintf("📜 %d", n), Data: scheduleData(actionHistory, it.ID)},
+		})
+	}
+	b.WriteString(i18n.T(lang, "schedules.legend"))
+
+	return transport.Message{ChatID: chatID, Text: b.String(), Keyboard: keyboard}, ""
+}
+
+// handleCallback dispatches an inline button press and edits the message it came from.
+func (a *App) handleCallback(ctx context.Context, job transport.CronJob) {
+	cb := job.Callback
+	notice := ""
+	defer func() {
+		if ans, ok := a.consumer.(transport.Answerer); ok {
+			if err := ans.AnswerCallback(ctx, cb.ID, notice); err != nil {
+				a.logger.Warn("failed to answer callback", slog.Any("err", err), slog.Int64("chat_id", job.ChatID))
+			}
+		}
+	}()
+
+	if a.subs == nil {
+		return
+	}
+	lang := a.language(ctx, job.ChatID)
+	switch {
+	case strings.HasPrefix(cb.Data, schedulePrefix):
+		action, id, _ := strings.Cut(strings.TrimPrefix(cb.Data, schedulePrefix), ":")
+		notice = a.scheduleAction(ctx, job.ChatID, cb.MessageID, lang, action, id)
+	case strings.HasPrefix(cb.Data, wizardPrefix):
+		notice = a.wizardCallback(ctx, job.ChatID, cb.MessageID, lang, cb.Data)
+	}
+}
+
+// scheduleAction applies a schedule button action, updates the message and returns
+// a short notice for the button press.
+func (a *App) scheduleAction(ctx context.Context, chatID int64, messageID int, lang, action, id string) string {
+	if action != actionList && !a.ownsSchedule(ctx, chatID, id) {
+		a.editScheduleList(ctx, chatID, messageID, lang)
+		return i18n.T(lang, "schedules.not_found")
+	}
+
+	log := a.logger.With(slog.Int64("chat_id", chatID), slog.String("scheduler_id", id), slog.String("action", action))
+	switch action {
+	case actionList:
+		a.editScheduleList(ctx, chatID, messageID, lang)
+		return ""
+
+	case actionPause, actionResume:
+		paused := action == actionPause
+		if err := a.subs.SetSchedulerPaused(ctx, chatID, id, paused); err != nil {
+			log.Error("failed to pause scheduler", slog.Any("err", err))
+			return i18n.T(lang, "schedules.action_failed")
+		}
+		if a.sched != nil {
+			if paused {
+				a.sched.Remove(ctx, id)
+			} else if err := a.sched.AddByID(ctx, id); err != nil {
+				log.Error("failed to register scheduler in runtime", slog.Any("err", err))
+			}
+		}
+		log.Info("scheduler paused", slog.Bool("paused", paused))
+		a.editScheduleList(ctx, chatID, messageID, lang)
+		if paused {
+			return i18n.T(lang, "schedules.paused_done")
+		}
+		return i18n.T(lang, "schedules.resumed_done")
+
+	case actionAskStop:
+		_ = a.producer.Send(ctx, transport.Message{
+			ChatID:        chatID,
+			EditMessageID: messageID,
+			Text:          i18n.T(lang, "schedules.stop_confirm", id),
+			Keyboard: [][]transport.Button{{
+				{Text: i18n.T(lang, "schedules.button_stop"), Data: scheduleData(actionStop, id)},
+				{Text: i18n.T(lang, "schedules.button_back"), Data: scheduleData(actionList, "")},
+			}},
+		})
+		return ""
+
+	case actionStop:
+		if err := a.subs.StopScheduler(ctx, chatID, id); err != nil {
+			log.Error("failed to stop scheduler", slog.Any("err", err))
+			return i18n.T(lang, "stop.failed")
+		}
+		if a.sched != nil {
+			a.sched.Remove(ctx, id)
+		}
+		log.Info("scheduler stopped")
+		a.editScheduleList(ctx, chatID, messageID, lang)
+		return i18n.T(lang, "stop.done")
+
+	case actionRun:
+		if a.sched == nil {
+			return i18n.T(lang, "schedules.action_failed")
+		}
+		if err := a.sched.RunNow(ctx, id); err != nil {
+			log.Error("failed to run scheduler", slog.Any("err", err))
+			return i18n.T(lang, "schedules.action_failed")
+		}
+		log.Info("scheduler run requested")
+		return i18n.T(lang, "schedules.run_started")
+
+	case actionHistory:
+		runs, err := a.subs.ListRuns(ctx, chatID, id, historyLimit)
+		if err != nil {
+			log.Error("failed to list runs", slog.Any("err", err))
+			return i18n.T(lang, "schedules.action_failed")
+		}
+		_ = a.producer.Send(ctx, transport.Message{
+			ChatID:        chatID,
+			EditMessageID: messageID,
+			Text:          a.formatHistory(lang, id, runs),
+			Keyboard: [][]transport.Button{{
+				{Text: i18n.T(lang, "schedules.button_refresh"), Data: scheduleData(actionHistory, id)},
+				{Text: i18n.T(lang, "schedules.button_back"), Data: scheduleData(actionList, "")},
+			}},
+		})
+		return ""
+	}
+	return ""
+}
+
+// ownsSchedule reports whether id is an active schedule of the chat.
+func (a *App) ownsSchedule(ctx context.Context, chatID int64, id string) bool {
+	items, err := a.subs.ListActiveSchedulers(ctx, chatID)
+	if err != nil {
+		a.logger.Error("failed to list schedulers", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		return false
+	}
+	for _, it := range items {
+		if it.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// editScheduleList replaces the message with the current schedule list.
+func (a *App) editScheduleList(ctx context.Context, chatID int64, messageID int, lang string) {
+	msg, key := a.scheduleList(ctx, chatID, lang)
+	if key != "" {
+		msg = transport.Message{ChatID: chatID, Text: i18n.T(lang, key)}
+	}
+	msg.EditMessageID = messageID
+	_ = a.producer.Send(ctx, msg)
+}
+
+// formatHistory renders the latest runs of a schedule, newest first.
+func (a *App) formatHistory(lang, id string, runs []domain.Run) string {
+	if len(runs) == 0 {
+		return i18n.T(lang, "schedules.history_empty", id)
+	}
+	loc, err := time.LoadLocation(a.timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	var b strings.Builder
+	b.WriteString(i18n.T(lang, "schedules.history_header", id))
+	for _, run := range runs {
+		b.WriteString("\n")
+		status := i18n.T(lang, "schedules.status."+run.Status)
+		if run.Delivery != "" {
+			status += ", " + i18n.T(lang, "schedules.delivery."+run.Delivery)
+		}
+		b.WriteString(i18n.T(lang, "schedules.history_item", run.ScheduledFor.In(loc).Format(i18n.TimeLayout(lang)), status))
+		if run.Error != "" {
+			b.WriteString(": " + render.Truncate(120, run.Error))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/transport"
+)
+
+// press simulates a button press on message 42 of chat 1 and returns the callback answer.
+func press(t *testing.T, a *App, consumer *answerer, data string) string {
+	t.Helper()
+	a.handleCallback(context.Background(), transport.CronJob{ChatID: 1, Callback: &transport.Callback{ID: "cb", MessageID: 42, Data: data}})
+	if len(consumer.notices) == 0 {
+		t.Fatalf("%s: callback not answered", data)
+	}
+	return consumer.notices[len(consumer.notices)-1]
+}
+
+func TestScheduleData(t *testing.T) {
+	data := scheduleData(actionHistory, "0b5e1f3c-8d7a-4c2e-9f1b-6a2d3e4f5a6b")
+	if len(data) > 64 {
+		t.Errorf("callback data %q is %d bytes, Telegram allows 64", data, len(data))
+	}
+	action, id, _ := strings.Cut(strings.TrimPrefix(data, schedulePrefix), ":")
+	if action != actionHistory || id != "0b5e1f3c-8d7a-4c2e-9f1b-6a2d3e4f5a6b" {
+		t.Errorf("parsed %q, %q", action, id)
+	}
+}
+
+func TestScheduleActions(t *testing.T) {
+	a, repo, producer, consumer := newTestApp(t)
+	repo.schedulers[1] = []domain.Scheduler{{ID: "s1", Expr: "0 0 7 * * *"}}
+
+	if got := press(t, a, consumer, scheduleData(actionPause, "s1")); got != "schedule paused" {
+		t.Errorf("pause: notice %q", got)
+	}
+	edit := producer.last(t)
+	if !repo.schedulers[1][0].Paused || edit.EditMessageID != 42 || !strings.Contains(edit.Text, "paused") {
+		t.Errorf("pause: paused %v, edit %+v", repo.schedulers[1][0].Paused, edit)
+	}
+	if got := edit.Keyboard[0][0].Data; got != scheduleData(actionResume, "s1") {
+		t.Errorf("pause: toggle button %q, want resume", got)
+	}
+
+	if got := press(t, a, consumer, scheduleData(actionResume, "s1")); got != "schedule resumed" || repo.schedulers[1][0].Paused {
+		t.Errorf("resume: notice %q, paused %v", got, repo.schedulers[1][0].Paused)
+	}
+
+	if got := press(t, a, consumer, scheduleData(actionRun, "s1")); got != "run started" {
+		t.Errorf("run: notice %q", got)
+	}
+	select {
+	case id := <-repo.ran:
+		if id != "s1" {
+			t.Errorf("run: ran %q", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run: schedule did not run")
+	}
+
+	repo.runs = []domain.Run{{ScheduledFor: time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC), Status: "error", Error: "boom", Delivery: "failed"}}
+	press(t, a, consumer, scheduleData(actionHistory, "s1"))
+	edit = producer.last(t)
+	if edit.EditMessageID != 42 || !strings.Contains(edit.Text, "last runs of s1") || !strings.Contains(edit.Text, "error, not delivered: boom") {
+		t.Errorf("history: edit %+v", edit)
+	}
+	if got := edit.Keyboard[0][1].Data; got != scheduleData(actionList, "") {
+		t.Errorf("history: back button %q", got)
+	}
+
+	press(t, a, consumer, scheduleData(actionAskStop, "s1"))
+	edit = producer.last(t)
+	if len(repo.schedulers[1]) != 1 || edit.EditMessageID != 42 || edit.Keyboard[0][0].Data != scheduleData(actionStop, "s1") {
+		t.Errorf("ask stop: schedulers %v, edit %+v", repo.schedulers[1], edit)
+	}
+
+	if got := press(t, a, consumer, scheduleData(actionStop, "s1")); got != "scheduler stopped" || len(repo.schedulers[1]) != 0 {
+		t.Errorf("stop: notice %q, schedulers %v", got, repo.schedulers[1])
+	}
+	if edit := producer.last(t); edit.EditMessageID != 42 || edit.Text != "no active schedulers" {
+		t.Errorf("stop: edit %+v, want the empty list", edit)
+	}
+}
+
+func TestScheduleActionChecksOwner(t *testing.T) {
+	a, repo, producer, consumer := newTestApp(t)
+	repo.schedulers[1] = []domain.Scheduler{{ID: "mine", Expr: "0 0 7 * * *"}}
+	repo.schedulers[2] = []domain.Scheduler{{ID: "theirs", Expr: "0 0 7 * * *"}}
+
+	for _, action := range []string{actionPause, actionResume, actionAskStop, actionStop, actionRun, actionHistory} {
+		if got := press(t, a, consumer, scheduleData(action, "theirs")); got != "schedule not found" {
+			t.Errorf("%s: notice %q", action, got)
+		}
+		// The stale message is replaced with the chat's own list.
+		if edit := producer.last(t); edit.EditMessageID != 42 || !strings.Contains(edit.Text, "mine") || strings.Contains(edit.Text, "theirs") {
+			t.Errorf("%s: edit %+v", action, edit)
+		}
+	}
+	if s := repo.schedulers[2]; len(s) != 1 || s[0].Paused {
+		t.Errorf("other chat's schedule changed: %+v", s)
+	}
+}
+
+func TestScheduleActionUnknownData(t *testing.T) {
+	a, repo, producer, consumer := newTestApp(t)
+	repo.schedulers[1] = []domain.Scheduler{{ID: "s1", Expr: "0 0 7 * * *"}}
+
+	tests := []struct {
+		data, notice string
+		edits        int
+	}{
+		{"sch:bogus:s1", "", 0},
+		{"sch:", "schedule not found", 1},
+		{"other:pause:s1", "", 0},
+	}
+	for _, tt := range tests {
+		sent := len(producer.msgs)
+		if got := press(t, a, consumer, tt.data); got != tt.notice {
+			t.Errorf("%q: notice %q, want %q", tt.data, got, tt.notice)
+		}
+		if n := len(producer.msgs) - sent; n != tt.edits {
+			t.Errorf("%q: sent %d messages, want %d", tt.data, n, tt.edits)
+		}
+	}
+	if repo.schedulers[1][0].Paused {
+		t.Error("unknown data paused the schedule")
+	}
+}
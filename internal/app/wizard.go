@@ -0,0 +1,421 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/i18n"
+	"cron-weather/internal/scheduler"
+	"cron-weather/internal/storage"
+	"cron-weather/internal/transport"
+)
+
+// Wizard buttons send "new:<action>:<value>" as callback data.
+const wizardPrefix = "new:"
+
+// Wizard button actions.
+const (
+	wizardActKind   = "kind"
+	wizardActLoc    = "loc"
+	wizardActFreq   = "freq"
+	wizardActTime   = "time"
+	wizardActHours  = "hours"
+	wizardActEnd    = "end"
+	wizardActCancel = "cancel"
+)
+
+// Frequency presets.
+const (
+	freqDaily = "daily"
+	freqHours = "hours"
+	freqCron  = "cron"
+)
+
+// End date presets.
+const (
+	endNever = "never"
+	endWeek  = "week"
+	endMonth = "month"
+)
+
+// wizardTTL is how long an unfinished wizard waits for the next answer.
+const wizardTTL = time.Hour
+
+var (
+	wizardKinds = []string{"weather", "air", "nowcast"}
+	wizardTimes = []string{"06:00", "07:00", "08:00", "09:00"}
+	wizardHours = []int{1, 2, 3, 6, 12}
+)
+
+func wizardData(action, value string) string {
+	return wizardPrefix + action + ":" + value
+}
+
+// cmdNew starts the /new schedule wizard, replacing an unfinished one.
+func (a *App) cmdNew(ctx context.Context, chatID int64) {
+	if a.subs == nil {
+		a.reply(ctx, chatID, "no_storage")
+		return
+	}
+	sub, err := a.subs.GetSubscription(ctx, chatID)
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && !sub.IsActive) {
+		a.reply(ctx, chatID, "new.no_subscription")
+		return
+	}
+	if err != nil {
+		a.logger.Error("failed to load subscription", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		a.reply(ctx, chatID, "new.failed")
+		return
+	}
+
+	w := domain.Wizard{ChatID: chatID, Step: domain.WizardKind}
+	switch kinds := a.wizardKinds(); len(kinds) {
+	case 0:
+		a.reply(ctx, chatID, "new.failed")
+		return
+	case 1:
+		w.Kind = kinds[0]
+		w.Step = domain.WizardLocation
+	}
+	lang := a.language(ctx, chatID)
+	a.wizardAdvance(ctx, lang, w, 0)
+}
+
+// cmdCancel aborts the /new wizard.
+func (a *App) cmdCancel(ctx context.Context, chatID int64) {
+	if a.subs == nil {
+		a.reply(ctx, chatID, "no_storage")
+		return
+	}
+	if err := a.subs.DeleteWizard(ctx, chatID); err != nil {
+		a.logger.Error("failed to delete wizard", slog.Any("err", err), slog.Int64("chat_id", chatID))
+	}
+	a.reply(ctx, chatID, "new.cancelled")
+}
+
+// wizardKinds returns the schedule kinds the engine can run.
+func (a *App) wizardKinds() []string {
+	var kinds []string
+	for _, k := range wizardKinds {
+		if a.sched != nil && a.sched.HasKind(k) {
+			kinds = append(kinds, k)
+		}
+	}
+	return kinds
+}
+
+// wizard loads the unfinished wizard of the chat; ok is false when there is none or it expired.
+func (a *App) wizard(ctx context.Context, chatID int64) (domain.Wizard, bool) {
+	if a.subs == nil {
+		return domain.Wizard{}, false
+	}
+	w, err := a.subs.GetWizard(ctx, chatID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			a.logger.Error("failed to load wizard", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		}
+		return domain.Wizard{}, false
+	}
+	if time.Since(w.UpdatedAt) > wizardTTL {
+		_ = a.subs.DeleteWizard(ctx, chatID)
+		return domain.Wizard{}, false
+	}
+	return w, true
+}
+
+// wizardCallback applies a wizard button press and returns a notice for it.
+func (a *App) wizardCallback(ctx context.Context, chatID int64, messageID int, lang, data string) string {
+	action, value, _ := strings.Cut(strings.TrimPrefix(data, wizardPrefix), ":")
+
+	if action == wizardActCancel {
+		if err := a.subs.DeleteWizard(ctx, chatID); err != nil {
+			a.logger.Error("failed to delete wizard", slog.Any("err", err), slog.Int64("chat_id", chatID))
+		}
+		a.send(ctx, chatID, messageID, i18n.T(lang, "new.cancelled"))
+		return ""
+	}
+
+	w, ok := a.wizard(ctx, chatID)
+	if !ok || !wizardAccepts(w.Step, action) {
+		a.send(ctx, chatID, messageID, i18n.T(lang, "new.expired"))
+		return i18n.T(lang, "new.expired")
+	}
+
+	switch action {
+	case wizardActKind:
+		if !slices.Contains(a.wizardKinds(), value) {
+			return i18n.T(lang, "start.unknown_kind", value)
+		}
+		w.Kind = value
+		w.Step = domain.WizardLocation
+	case wizardActLoc:
+		w.LocationID = value
+		w.Step = domain.WizardFrequency
+	case wizardActFreq:
+		switch value {
+		case freqDaily:
+			w.Step = domain.WizardTime
+		case freqHours:
+			w.Step = domain.WizardHours
+		default:
+			w.Step = domain.WizardCron
+		}
+	case wizardActTime:
+		expr, ok := dailyExpr(value)
+		if !ok {
+			return i18n.T(lang, "new.invalid_time")
+		}
+		w.Expr = expr
+		w.Step = domain.WizardEnd
+	case wizardActHours:
+		expr, ok := hoursExpr(value)
+		if !ok {
+			return i18n.T(lang, "new.invalid_hours")
+		}
+		w.Expr = expr
+		w.Step = domain.WizardEnd
+	case wizardActEnd:
+		var end *time.Time
+		switch value {
+		case endWeek:
+			t := time.Now().AddDate(0, 0, 7)
+			end = &t
+		case endMonth:
+			t := time.Now().AddDate(0, 1, 0)
+			end = &t
+		}
+		a.wizardFinish(ctx, lang, w, end, messageID)
+		return ""
+	}
+	a.wizardAdvance(ctx, lang, w, messageID)
+	return ""
+}
+
+// wizardAccepts reports whether a button action belongs to the current step,
+// so presses on old wizard messages are ignored.
+func wizardAccepts(step, action string) bool {
+	switch action {
+	case wizardActKind:
+		return step == domain.WizardKind
+	case wizardActLoc:
+		return step == domain.WizardLocation
+	case wizardActFreq:
+		return step == domain.WizardFrequency
+	case wizardActTime:
+		return step == domain.WizardTime
+	case wizardActHours:
+		return step == domain.WizardHours
+	case wizardActEnd:
+		return step == domain.WizardEnd
+	}
+	return false
+}
+
+// handleText feeds a plain text message to an unfinished wizard.
+func (a *App) handleText(ctx context.Context, chatID int64, text string) {
+	w, ok := a.wizard(ctx, chatID)
+	if !ok {
+		return
+	}
+	lang := a.language(ctx, chatID)
+
+	switch w.Step {
+	case domain.WizardTime:
+		expr, ok := dailyExpr(text)
+		if !ok {
+			a.reply(ctx, chatID, "new.invalid_time")
+			return
+		}
+		w.Expr = expr
+		w.Step = domain.WizardEnd
+	case domain.WizardHours:
+		expr, ok := hoursExpr(text)
+		if !ok {
+			a.reply(ctx, chatID, "new.invalid_hours")
+			return
+		}
+		w.Expr = expr
+		w.Step = domain.WizardEnd
+	case domain.WizardCron:
+		expr := strings.TrimSpace(text)
+		if err := scheduler.ValidateSpec(expr); err != nil {
+			a.reply(ctx, chatID, "new.invalid_cron", err.Error())
+			return
+		}
+		w.Expr = expr
+		w.Step = domain.WizardEnd
+	case domain.WizardEnd:
+		end, ok := a.parseEndDate(text)
+		if !ok {
+			a.reply(ctx, chatID, "new.invalid_end")
+			return
+		}
+		a.wizardFinish(ctx, lang, w, end, 0)
+		return
+	}
+	// Steps answered with buttons get their question again.
+	a.wizardAdvance(ctx, lang, w, 0)
+}
+
+// wizardAdvance stores the wizard and asks the question of its step,
+// editing messageID in place when it is set.
+func (a *App) wizardAdvance(ctx context.Context, lang string, w domain.Wizard, messageID int) {
+	var text string
+	var rows [][]transport.Button
+
+	if w.Step == domain.WizardLocation {
+		locs, err := a.subs.ListLocations(ctx, w.ChatID)
+		if err != nil {
+			a.logger.Error("failed to list locations", slog.Any("err", err), slog.Int64("chat_id", w.ChatID))
+		}
+		if len(locs) == 0 {
+			// Without named places the subscription coordinates are the only choice.
+			w.Step = domain.WizardFrequency
+		} else {
+			text = i18n.T(lang, "new.ask_location")
+			rows = append(rows, []transport.Button{{Text: i18n.T(lang, "new.location_default"), Data: wizardData(wizardActLoc, "")}})
+			for _, l := range locs {
+				rows = append(rows, []transport.Button{{Text: "📍 " + l.Name, Data: wizardData(wizardActLoc, l.ID)}})
+			}
+		}
+	}
+
+	switch w.Step {
+	case domain.WizardKind:
+		text = i18n.T(lang, "new.ask_kind")
+		for _, k := range a.wizardKinds() {
+			rows = append(rows, []transport.Button{{Text: i18n.T(lang, "new.kind."+k), Data: wizardData(wizardActKind, k)}})
+		}
+	case domain.WizardFrequency:
+		text = i18n.T(lang, "new.ask_frequency")
+		rows = [][]transport.Button{
+			{{Text: i18n.T(lang, "new.freq_daily"), Data: wizardData(wizardActFreq, freqDaily)}},
+			{{Text: i18n.T(lang, "new.freq_hours"), Data: wizardData(wizardActFreq, freqHours)}},
+			{{Text: i18n.T(lang, "new.freq_cron"), Data: wizardData(wizardActFreq, freqCron)}},
+		}
+	case domain.WizardTime:
+		text = i18n.T(lang, "new.ask_time", a.timezone)
+		row := make([]transport.Button, 0, len(wizardTimes))
+		for _, t := range wizardTimes {
+			row = append(row, transport.Button{Text: t, Data: wizardData(wizardActTime, t)})
+		}
+		rows = [][]transport.Button{row}
+	case domain.WizardHours:
+		text = i18n.T(lang, "new.ask_hours")
+		row := make([]transport.Button, 0, len(wizardHours))
+		for _, h := range wizardHours {
+			v := strconv.Itoa(h)
+			row = append(row, transport.Button{Text: v, Data: wizardData(wizardActHours, v)})
+		}
+		rows = [][]transport.Button{row}
+	case domain.WizardCron:
+		text = i18n.T(lang, "new.ask_cron")
+	case domain.WizardEnd:
+		text = i18n.T(lang, "new.ask_end", w.Expr)
+		rows = [][]transport.Button{{
+			{Text: i18n.T(lang, "new.end_never"), Data: wizardData(wizardActEnd, endNever)},
+			{Text: i18n.T(lang, "new.end_week"), Data: wizardData(wizardActEnd, endWeek)},
+			{Text: i18n.T(lang, "new.end_month"), Data: wizardData(wizardActEnd, endMonth)},
+		}}
+	}
+	rows = append(rows, []transport.Button{{Text: i18n.T(lang, "new.button_cancel"), Data: wizardData(wizardActCancel, "")}})
+
+	if err := a.subs.SaveWizard(ctx, w); err != nil {
+		a.logger.Error("failed to save wizard", slog.Any("err", err), slog.Int64("chat_id", w.ChatID))
+		a.reply(ctx, w.ChatID, "new.failed")
+		return
+	}
+	_ = a.producer.Send(ctx, transport.Message{ChatID: w.ChatID, Text: text, Keyboard: rows, EditMessageID: messageID})
+}
+
+// wizardFinish creates the schedule collected by the wizard through the same path as /start.
+func (a *App) wizardFinish(ctx context.Context, lang string, w domain.Wizard, end *time.Time, messageID int) {
+	if err := a.subs.DeleteWizard(ctx, w.ChatID); err != nil {
+		a.logger.Error("failed to delete wizard", slog.Any("err", err), slog.Int64("chat_id", w.ChatID))
+	}
+
+	s := domain.Scheduler{
+		Kind:       w.Kind,
+		Expr:       w.Expr,
+		TZ:         a.timezone,
+		EndAt:      end,
+		LocationID: w.LocationID,
+	}
+	id, err := a.subs.CreateScheduler(ctx, w.ChatID, s)
+	if err != nil {
+		a.logger.Error("failed to create scheduler", slog.Any("err", err), slog.Int64("chat_id", w.ChatID))
+		a.send(ctx, w.ChatID, messageID, i18n.T(lang, "start.failed"))
+		return
+	}
+
+	a.logger.Info("scheduler created",
+		slog.String("scheduler_id", id),
+		slog.Int64("chat_id", w.ChatID),
+		slog.String("cron_expr", s.Expr),
+		slog.String("location_id", s.LocationID),
+		slog.String("kind", s.Kind),
+		slog.String("end_at", formatTime(end)),
+		slog.Bool("wizard", true),
+	)
+	a.send(ctx, w.ChatID, messageID, i18n.T(lang, "new.created", id, s.Expr, formatTime(end)))
+
+	if a.sched != nil {
+		if err := a.sched.AddByID(ctx, id); err != nil {
+			a.logger.Error("failed to register scheduler in runtime", slog.Any("err", err), slog.String("scheduler_id", id))
+		}
+	}
+}
+
+// send replaces the text of messageID and removes its keyboard, or sends a new message when it is 0.
+func (a *App) send(ctx context.Context, chatID int64, messageID int, text string) {
+	_ = a.producer.Send(ctx, transport.Message{ChatID: chatID, Text: text, EditMessageID: messageID})
+}
+
+// dailyExpr builds a cron expression running every day at "HH:MM".
+func dailyExpr(s string) (string, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("0 %d %d * * *", t.Minute(), t.Hour()), true
+}
+
+// hoursExpr builds a cron expression running every n hours (1..23) from midnight.
+func hoursExpr(s string) (string, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 1 || n > 23 {
+		return "", false
+	}
+	if n == 1 {
+		return "0 0 * * * *", true
+	}
+	return fmt.Sprintf("0 0 */%d * * *", n), true
+}
+
+// parseEndDate parses "-" (no end) or a YYYY-MM-DD date; the schedule ends after that day.
+func (a *App) parseEndDate(s string) (*time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "-" {
+		return nil, true
+	}
+	loc, err := time.LoadLocation(a.timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	d, err := time.ParseInLocation("2006-01-02", s, loc)
+	if err != nil {
+		return nil, false
+	}
+	end := d.AddDate(0, 0, 1)
+	if !end.After(time.Now()) {
+		return nil, false
+	}
+	return &end, true
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/scheduler"
+	"cron-weather/internal/task"
+)
+
+func newWizardApp(t *testing.T) (*App, *memRepo, *recordingProducer, *answerer) {
+	t.Helper()
+	a, repo, producer, consumer := newTestApp(t)
+	repo.sub = &domain.Subscription{ID: "sub", IsActive: true}
+	return a, repo, producer, consumer
+}
+
+func TestWizardButtons(t *testing.T) {
+	a, repo, producer, consumer := newWizardApp(t)
+	repo.locations = []domain.Location{{ID: "l1", Name: "Home"}}
+	ctx := context.Background()
+
+	a.cmdNew(ctx, 1)
+	if w := repo.wizards[1]; w.Step != domain.WizardKind {
+		t.Fatalf("after /new: step %q", w.Step)
+	}
+	if msg := producer.last(t); len(msg.Keyboard) != 3 || msg.Keyboard[1][0].Data != "new:kind:air" {
+		t.Fatalf("kind question: %+v", msg)
+	}
+
+	steps := []struct {
+		data string
+		want string
+	}{
+		{wizardData(wizardActKind, "air"), domain.WizardLocation},
+		{wizardData(wizardActLoc, "l1"), domain.WizardFrequency},
+		{wizardData(wizardActFreq, freqHours), domain.WizardHours},
+		{wizardData(wizardActHours, "6"), domain.WizardEnd},
+	}
+	for _, st := range steps {
+		if got := press(t, a, consumer, st.data); got != "" {
+			t.Errorf("%s: notice %q", st.data, got)
+		}
+		if w := repo.wizards[1]; w.Step != st.want {
+			t.Fatalf("%s: step %q, want %q", st.data, w.Step, st.want)
+		}
+		if msg := producer.last(t); msg.EditMessageID != 42 {
+			t.Errorf("%s: question not edited in place: %+v", st.data, msg)
+		}
+	}
+
+	press(t, a, consumer, wizardData(wizardActEnd, endWeek))
+	if _, ok := repo.wizards[1]; ok {
+		t.Error("wizard kept after finishing")
+	}
+	s := repo.schedulers[1]
+	if len(s) != 1 || s[0].Kind != "air" || s[0].LocationID != "l1" || s[0].Expr != "0 0 */6 * * *" || s[0].EndAt == nil {
+		t.Fatalf("created %+v", s)
+	}
+	if msg := producer.last(t); msg.EditMessageID != 42 || !strings.HasPrefix(msg.Text, "schedule created: s1") {
+		t.Errorf("finish: %+v", msg)
+	}
+}
+
+func TestWizardText(t *testing.T) {
+	tests := []struct {
+		name     string
+		step     string
+		text     string
+		wantStep string // "" when the wizard finishes
+		wantExpr string
+		reply    string // prefix of the reply
+	}{
+		{"daily time", domain.WizardTime, " 7:30 ", domain.WizardEnd, "0 30 7 * * *", "schedule: 0 30 7 * * *"},
+		{"invalid time", domain.WizardTime, "25:00", domain.WizardTime, "", "invalid time"},
+		{"hours", domain.WizardHours, "3", domain.WizardEnd, "0 0 */3 * * *", "schedule: "},
+		{"invalid hours", domain.WizardHours, "24", domain.WizardHours, "", "send a number of hours"},
+		{"cron with spaces", domain.WizardCron, "  0 30 7 * * 1-5\n", domain.WizardEnd, "0 30 7 * * 1-5", "schedule: 0 30 7 * * 1-5."},
+		{"invalid cron", domain.WizardCron, "every day", domain.WizardCron, "", "invalid cron expression"},
+		{"no end", domain.WizardEnd, "-", "", "", "schedule created"},
+		{"past end", domain.WizardEnd, "2020-01-01", domain.WizardEnd, "", "send a future date"},
+		{"button step asks again", domain.WizardFrequency, "daily", domain.WizardFrequency, "", "how often"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, repo, producer, _ := newWizardApp(t)
+			repo.wizards[1] = domain.Wizard{ChatID: 1, Step: tt.step, Kind: "weather", Expr: "0 0 7 * * *", UpdatedAt: time.Now()}
+
+			a.handleText(context.Background(), 1, tt.text)
+			w, ok := repo.wizards[1]
+			switch {
+			case tt.wantStep == "" && ok:
+				t.Errorf("wizard kept at step %q", w.Step)
+			case tt.wantStep != "" && (!ok || w.Step != tt.wantStep):
+				t.Errorf("step %q, want %q", w.Step, tt.wantStep)
+			case tt.wantExpr != "" && w.Expr != tt.wantExpr:
+				t.Errorf("expr %q, want %q", w.Expr, tt.wantExpr)
+			}
+			if msg := producer.last(t); !strings.HasPrefix(msg.Text, tt.reply) {
+				t.Errorf("reply %q, want prefix %q", msg.Text, tt.reply)
+			}
+		})
+	}
+}
+
+func TestWizardExpires(t *testing.T) {
+	a, repo, producer, consumer := newWizardApp(t)
+	stale := domain.Wizard{ChatID: 1, Step: domain.WizardCron, Kind: "weather", UpdatedAt: time.Now().Add(-wizardTTL - time.Minute)}
+
+	repo.wizards[1] = stale
+	a.handleText(context.Background(), 1, "0 0 7 * * *")
+	if _, ok := repo.wizards[1]; ok || len(producer.msgs) != 0 || len(repo.schedulers[1]) != 0 {
+		t.Errorf("expired wizard answered text: wizards %v, sent %v", repo.wizards, producer.msgs)
+	}
+
+	stale.Step = domain.WizardEnd
+	repo.wizards[1] = stale
+	if got := press(t, a, consumer, wizardData(wizardActEnd, endNever)); !strings.Contains(got, "expired") {
+		t.Errorf("button on expired wizard: notice %q", got)
+	}
+	if len(repo.schedulers[1]) != 0 {
+		t.Errorf("expired wizard created %+v", repo.schedulers[1])
+	}
+}
+
+func TestWizardIgnoresOldButtons(t *testing.T) {
+	a, repo, _, consumer := newWizardApp(t)
+	repo.wizards[1] = domain.Wizard{ChatID: 1, Step: domain.WizardFrequency, Kind: "weather", UpdatedAt: time.Now()}
+
+	// The kind question was answered already.
+	if got := press(t, a, consumer, wizardData(wizardActKind, "air")); !strings.Contains(got, "expired") {
+		t.Errorf("notice %q", got)
+	}
+	if w := repo.wizards[1]; w.Step != domain.WizardFrequency || w.Kind != "weather" {
+		t.Errorf("wizard changed: %+v", w)
+	}
+
+	if got := press(t, a, consumer, wizardData(wizardActCancel, "")); got != "" {
+		t.Errorf("cancel: notice %q", got)
+	}
+	if _, ok := repo.wizards[1]; ok {
+		t.Error("wizard kept after cancel")
+	}
+}
+
+func TestWizardSingleKindSkipsQuestion(t *testing.T) {
+	a, repo, _, _ := newWizardApp(t)
+	a.sched = scheduler.New(a.logger, repo, a.producer, map[string]task.Runner{"weather": idleRunner{}}, "UTC")
+
+	a.cmdNew(context.Background(), 1)
+	// Without named places the location question is skipped as well.
+	if w := repo.wizards[1]; w.Kind != "weather" || w.Step != domain.WizardFrequency {
+		t.Errorf("wizard %+v, want weather at the frequency step", w)
+	}
+}
+
+func TestWizardAccepts(t *testing.T) {
+	steps := map[string]string{
+		wizardActKind:  domain.WizardKind,
+		wizardActLoc:   domain.WizardLocation,
+		wizardActFreq:  domain.WizardFrequency,
+		wizardActTime:  domain.WizardTime,
+		wizardActHours: domain.WizardHours,
+		wizardActEnd:   domain.WizardEnd,
+	}
+	all := []string{domain.WizardKind, domain.WizardLocation, domain.WizardFrequency, domain.WizardTime, domain.WizardHours, domain.WizardCron, domain.WizardEnd}
+	for action, step := range steps {
+		for _, s := range all {
+			if got := wizardAccepts(s, action); got != (s == step) {
+				t.Errorf("wizardAccepts(%q, %q) = %v", s, action, got)
+			}
+		}
+	}
+	if wizardAccepts(domain.WizardCron, wizardActCancel) || wizardAccepts(domain.WizardKind, "bogus") {
+		t.Error("wizardAccepts took an action without a step")
+	}
+}
+
+func TestDailyExpr(t *testing.T) {
+	tests := map[string]string{"07:30": "0 30 7 * * *", " 7:05 ": "0 5 7 * * *", "00:00": "0 0 0 * * *", "23:59": "0 59 23 * * *"}
+	for in, want := range tests {
+		if got, ok := dailyExpr(in); !ok || got != want {
+			t.Errorf("dailyExpr(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"", "24:00", "7", "07:60", "7.30"} {
+		if got, ok := dailyExpr(in); ok {
+			t.Errorf("dailyExpr(%q) = %q, want invalid", in, got)
+		}
+	}
+}
+
+func TestHoursExpr(t *testing.T) {
+	tests := map[string]string{"1": "0 0 * * * *", "6": "0 0 */6 * * *", " 23 ": "0 0 */23 * * *"}
+	for in, want := range tests {
+		if got, ok := hoursExpr(in); !ok || got != want {
+			t.Errorf("hoursExpr(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"", "0", "24", "-1", "two"} {
+		if got, ok := hoursExpr(in); ok {
+			t.Errorf("hoursExpr(%q) = %q, want invalid", in, got)
+		}
+	}
+	for _, h := range wizardHours {
+		expr, _ := hoursExpr(strconv.Itoa(h))
+		if err := scheduler.ValidateSpec(expr); err != nil {
+			t.Errorf("preset %d: %v", h, err)
+		}
+	}
+}
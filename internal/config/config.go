@@ -2,10 +2,14 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
@@ -15,6 +19,18 @@ import (
 type Config struct {
 	Env      string `env:"ENV" envDefault:"prod"`
 	Timezone string `env:"TZ" envDefault:"UTC"`
+	// Language is the default language for subscriptions without /language set.
+	Language string `env:"DEFAULT_LANG" envDefault:"ru"`
+	// WeatherProvider is an ordered list of weather data sources (openweather, openmeteo);
+	// later providers are used when earlier ones fail.
+	WeatherProvider []string `env:"WEATHER_PROVIDER" envSeparator:"," envDefault:"openweather"`
+	// ProviderFailureThreshold is the number of consecutive failures that opens a provider circuit breaker.
+	ProviderFailureThreshold int `env:"WEATHER_PROVIDER_FAILURES" envDefault:"3"`
+	// ProviderProbeInterval is how often a provider with an open breaker is probed.
+	ProviderProbeInterval time.Duration `env:"WEATHER_PROVIDER_PROBE_INTERVAL" envDefault:"5m"`
+	// QuietBypassSeverity is the lowest message severity delivered during quiet hours
+	// (minor, moderate, severe, extreme); less severe messages are held until the window ends.
+	QuietBypassSeverity string `env:"QUIET_BYPASS_SEVERITY" envDefault:"severe"`
 
 	TgBot       TgBotConfig       `envPrefix:"TG_"`
 	Postgres    PostgressConfig   `envPrefix:"PG_"`
@@ -25,6 +41,29 @@ type Config struct {
 type TgBotConfig struct {
 	BotToken string `env:"BOT_TOKEN,required"`
 	Debug    bool   `env:"DEBUG" envDefault:"false"`
+	// AdminChatID receives service notifications such as API budget warnings (0 disables them).
+	AdminChatID int64 `env:"ADMIN_CHAT_ID" envDefault:"0"`
+
+	// RateGlobal and RateChat limit outgoing messages per second for the bot and for one private chat;
+	// RateGroupPerMinute limits one group chat.
+	RateGlobal         float64 `env:"RATE_GLOBAL" envDefault:"30"`
+	RateChat           float64 `env:"RATE_CHAT" envDefault:"1"`
+	RateGroupPerMinute float64 `env:"RATE_GROUP_PER_MINUTE" envDefault:"20"`
+	// SendMaxAttempts is how many times a queued message is tried before it is marked failed.
+	SendMaxAttempts int `env:"SEND_MAX_ATTEMPTS" envDefault:"5"`
+	// MergeMessages joins short messages of one run into one Telegram message.
+	MergeMessages bool `env:"MERGE_MESSAGES" envDefault:"true"`
+
+	// Mode selects how updates are received: "polling" (getUpdates) or "webhook".
+	Mode string `env:"MODE" envDefault:"polling"`
+	// WebhookURL is the public HTTPS URL Telegram posts updates to; its path is served on WebhookListen.
+	WebhookURL string `env:"WEBHOOK_URL"`
+	// WebhookListen is the address of the local webhook HTTP server.
+	WebhookListen string `env:"WEBHOOK_LISTEN" envDefault:":8080"`
+	// WebhookSecret is checked against the X-Telegram-Bot-Api-Secret-Token header of every update.
+	WebhookSecret string `env:"WEBHOOK_SECRET"`
+	// WebhookKeep leaves the webhook registered on shutdown, so other replicas keep receiving updates.
+	WebhookKeep bool `env:"WEBHOOK_KEEP" envDefault:"false"`
 }
 
 // PostgressConfig contains PostgreSQL connection settings.
@@ -53,8 +92,71 @@ func (p PostgressConfig) DSN() string {
 
 // OpenWeatherConfig contains OpenWeather API configuration.
 type OpenWeatherConfig struct {
-	APIKey     string `env:"API_KEY,required"`
+	// APIKey is required when WEATHER_PROVIDER is openweather.
+	APIKey string `env:"API_KEY"`
+	// APIKeyFile is a file with the API key (e.g. a Docker secret), used when APIKey is empty.
+	APIKeyFile string `env:"API_KEY_FILE"`
 	DailyLimit int    `env:"DAILY_LIMIT" envDefault:"1000"`
+
+	// BaseURL is the API root; override it to use a mock or a proxy.
+	BaseURL string `env:"BASE_URL" envDefault:"https://api.openweathermap.org"`
+	// Timeout bounds a single request attempt.
+	Timeout time.Duration `env:"TIMEOUT" envDefault:"12s"`
+	// MaxAttempts, BackoffBase and BackoffMax define the retry policy for 429 and 5xx responses.
+	MaxAttempts int           `env:"MAX_ATTEMPTS" envDefault:"4"`
+	BackoffBase time.Duration `env:"BACKOFF_BASE" envDefault:"600ms"`
+	BackoffMax  time.Duration `env:"BACKOFF_MAX" envDefault:"10s"`
+	// BodyLimit caps the response body size in bytes.
+	BodyLimit int64 `env:"BODY_LIMIT" envDefault:"2097152"`
+
+	// UrgentCooldown is the time bucket used to deduplicate repeated urgent weather notices.
+	UrgentCooldown time.Duration `env:"URGENT_COOLDOWN" envDefault:"1h"`
+	// UrgentAllClear enables an "all clear" message once urgent weather codes disappear.
+	UrgentAllClear bool `env:"URGENT_ALL_CLEAR" envDefault:"false"`
+
+	// CacheTTL is how long a One Call response is reused for nearby requests (0 disables the cache).
+	CacheTTL time.Duration `env:"CACHE_TTL" envDefault:"10m"`
+	// CacheShared additionally keeps cached responses in Postgres so replicas share them.
+	CacheShared bool `env:"CACHE_SHARED" envDefault:"false"`
+
+	// AirLevel is the air quality index (1 good .. 5 very poor) that triggers "air" schedule alerts.
+	AirLevel int `env:"AIR_AQI_LEVEL" envDefault:"4"`
+	// NowcastHorizon is how far ahead "nowcast" schedules announce rain (at most 1h).
+	NowcastHorizon time.Duration `env:"NOWCAST_HORIZON" envDefault:"30m"`
+
+	// BudgetDaily and BudgetMonthly cap API calls for the whole account (0 means unlimited).
+	BudgetDaily   int `env:"BUDGET_DAILY" envDefault:"1000"`
+	BudgetMonthly int `env:"BUDGET_MONTHLY" envDefault:"0"`
+	// BudgetDegradeInterval is how long quiet places reuse cached responses once 80% of the budget is used.
+	BudgetDegradeInterval time.Duration `env:"BUDGET_DEGRADE_INTERVAL" envDefault:"1h"`
+}
+
+// Secrets returns configured secret values that must never appear in logs or stored errors.
+func (c *Config) Secrets() []string {
+	return []string{c.TgBot.BotToken, c.TgBot.WebhookSecret, c.OpenWeather.APIKey, c.Postgres.Password}
+}
+
+// weatherProviders are the names accepted in WEATHER_PROVIDER.
+var weatherProviders = []string{"openweather", "openmeteo"}
+
+// providerNames trims and lowercases the WEATHER_PROVIDER entries, skips empty ones and
+// rejects unknown names. An empty list means openweather.
+func providerNames(raw []string) ([]string, error) {
+	out := make([]string, 0, len(raw))
+	for _, name := range raw {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(weatherProviders, name) {
+			return nil, fmt.Errorf("unknown provider %q (want one of %s)", name, strings.Join(weatherProviders, ", "))
+		}
+		out = append(out, name)
+	}
+	if len(out) == 0 {
+		out = append(out, "openweather")
+	}
+	return out, nil
 }
 
 // MustLoad loads configuration from .env (outside Docker) and the process environment.
@@ -72,5 +174,23 @@ func MustLoad() *Config {
 		log.Fatalf("failed to read env file: %v", err)
 	}
 
+	if cfg.OpenWeather.APIKey == "" && cfg.OpenWeather.APIKeyFile != "" {
+		b, err := os.ReadFile(cfg.OpenWeather.APIKeyFile)
+		if err != nil {
+			log.Fatalf("failed to read OWM_API_KEY_FILE: %v", err)
+		}
+		cfg.OpenWeather.APIKey = strings.TrimSpace(string(b))
+	}
+
+	providers, err := providerNames(cfg.WeatherProvider)
+	if err != nil {
+		log.Fatalf("WEATHER_PROVIDER: %v", err)
+	}
+	cfg.WeatherProvider = providers
+
+	if slices.Contains(cfg.WeatherProvider, "openweather") && cfg.OpenWeather.APIKey == "" {
+		log.Fatalf("OWM_API_KEY is required for WEATHER_PROVIDER=openweather")
+	}
+
 	return &cfg
 }
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProviderNames(t *testing.T) {
+	got, err := providerNames([]string{" OpenMeteo", " openweather ", ""})
+	if err != nil || !slices.Equal(got, []string{"openmeteo", "openweather"}) {
+		t.Errorf("providerNames = %q, %v", got, err)
+	}
+	if got, err := providerNames([]string{""}); err != nil || !slices.Equal(got, []string{"openweather"}) {
+		t.Errorf("providerNames(empty) = %q, %v, want openweather", got, err)
+	}
+	if _, err := providerNames([]string{"openweather", "yr"}); err == nil {
+		t.Error("providerNames accepted an unknown provider")
+	}
+}
@@ -0,0 +1,9 @@
+package domain
+
+// AlertState is the last delivered content of one weather alert identity.
+type AlertState struct {
+	Key      string
+	Snapshot string
+	// Active is false once the alert disappeared from the provider response.
+	Active bool
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// BudgetUsage is the account-wide API usage for the current UTC day and month.
+// A zero limit means unlimited.
+type BudgetUsage struct {
+	// Day and Month are period keys ("2006-01-02", "2006-01").
+	Day   string
+	Month string
+
+	DayUsed    int
+	DayLimit   int
+	MonthUsed  int
+	MonthLimit int
+}
+
+// BudgetPeriods returns the day and month period keys for t (UTC).
+func BudgetPeriods(t time.Time) (day, month string) {
+	t = t.UTC()
+	return t.Format("2006-01-02"), t.Format("2006-01")
+}
+
+// DayPercent returns the daily usage in percent of the limit (0 when unlimited).
+func (u BudgetUsage) DayPercent() int { return percent(u.DayUsed, u.DayLimit) }
+
+// MonthPercent returns the monthly usage in percent of the limit (0 when unlimited).
+func (u BudgetUsage) MonthPercent() int { return percent(u.MonthUsed, u.MonthLimit) }
+
+func percent(used, limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+	return used * 100 / limit
+}
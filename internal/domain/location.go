@@ -0,0 +1,11 @@
+package domain
+
+// Location is a named place of a subscription (e.g. "home", "dacha").
+// Schedules may target a location instead of the subscription coordinates.
+type Location struct {
+	ID             string
+	SubscriptionID string
+	Name           string
+	Lat            float64
+	Lon            float64
+}
@@ -0,0 +1,17 @@
+package domain
+
+// OutboxMessage is an outgoing chat message waiting in the persistent delivery queue.
+type OutboxMessage struct {
+	ID int64
+	// RunID is the runs row the message belongs to (0 for command replies and notifications).
+	RunID     int64
+	ChatID    int64
+	Text      string
+	ParseMode string
+	// ReplyMarkup is the inline keyboard encoded as JSON ("" for none).
+	ReplyMarkup string
+	// EditMessageID is the chat message replaced by this one (0 sends a new message).
+	EditMessageID int
+	// Attempts counts failed delivery attempts.
+	Attempts int
+}
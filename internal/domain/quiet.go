@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietHours is a daily do-not-disturb window. Start and End are minutes since midnight
+// in the subscription time zone; the window wraps midnight when End < Start (e.g. 22:00-07:00).
+type QuietHours struct {
+	Start int
+	End   int
+}
+
+// HeldMessage is a task message postponed until quiet hours end.
+type HeldMessage struct {
+	ID             int64
+	SubscriptionID string
+	Target         SchedulerTarget
+	Text           string
+	ParseMode      string
+	CreatedAt      time.Time
+	// Attempts counts failed deliveries after quiet hours.
+	Attempts int
+}
+
+// ParseQuietHours parses a "HH:MM-HH:MM" window.
+func ParseQuietHours(s string) (QuietHours, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("quiet hours must look like HH:MM-HH:MM")
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	if start == end {
+		return QuietHours{}, fmt.Errorf("quiet hours start and end must differ")
+	}
+	return QuietHours{Start: start, End: end}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether t (already in the subscription time zone) falls into the window.
+func (q QuietHours) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if q.Start < q.End {
+		return m >= q.Start && m < q.End
+	}
+	return m >= q.Start || m < q.End
+}
+
+// String formats the window as "HH:MM-HH:MM".
+func (q QuietHours) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", q.Start/60, q.Start%60, q.End/60, q.End%60)
+}
@@ -13,8 +13,22 @@ type Scheduler struct {
 	TZ             string
 	StartAt        *time.Time
 	EndAt          *time.Time
-	IsActive       bool
-	CreatedAt      time.Time
+	// LocationID is the target location ("" means subscription coordinates).
+	LocationID string
+	IsActive   bool
+	// Paused schedules stay active but do not run until resumed.
+	Paused    bool
+	CreatedAt time.Time
+}
+
+// Run is one recorded schedule execution.
+type Run struct {
+	ID           int64
+	ScheduledFor time.Time
+	Status       string
+	Error        string
+	// Delivery is the state of the run messages: pending, delivered, failed or "" when none were sent.
+	Delivery string
 }
 
 // SchedulerTarget describes where the scheduled job should deliver its output.
@@ -29,4 +43,6 @@ type SchedulerWithTarget struct {
 	Scheduler    Scheduler
 	Subscription Subscription
 	Target       SchedulerTarget
+	// Location is the schedule target location, nil for subscription coordinates.
+	Location *Location
 }
@@ -0,0 +1,37 @@
+package domain
+
+import "strings"
+
+// Severity ranks how important a task message is. The zero value is informational
+// (e.g. "alert ended" or "all clear").
+type Severity int
+
+// Severity levels in increasing order.
+const (
+	SeverityInfo Severity = iota
+	SeverityMinor
+	SeverityModerate
+	SeveritySevere
+	SeverityExtreme
+)
+
+var severityNames = []string{"info", "minor", "moderate", "severe", "extreme"}
+
+// String returns the lower-case severity name.
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return "unknown"
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity parses a severity name such as "severe".
+func ParseSeverity(name string) (Severity, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, n := range severityNames {
+		if n == name {
+			return Severity(i), true
+		}
+	}
+	return SeverityInfo, false
+}
@@ -1,11 +1,77 @@
 package domain
 
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Measurement units supported by weather providers (OpenWeather naming).
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+	UnitsStandard = "standard"
+)
+
 // Subscription represents an owner of schedules.
 // In current app it's 1:1 with a Telegram chat (via owner_ref and endpoint link).
 type Subscription struct {
 	ID       string
 	OwnerRef string
-	Lat      float64
-	Lon      float64
+	// Lat and Lon are nil while the location is unset.
+	Lat      *float64
+	Lon      *float64
 	IsActive bool
+	// ParseMode is the Telegram parse mode for task messages ("" means plain text).
+	ParseMode string
+	// Language is the subscription language code ("" means service default).
+	Language string
+	// Units is the measurement system for API calls and formatting ("" means metric).
+	Units string
+	// TZ is the IANA time zone for quiet hours ("" means service default).
+	TZ string
+	// Quiet is the do-not-disturb window, nil when disabled.
+	Quiet *QuietHours
+	// MinSeverity drops weather alerts below it (SeverityInfo delivers all alerts).
+	MinSeverity Severity
+}
+
+// InQuietHours reports whether now falls into the subscription quiet hours.
+// def is used when the subscription has no time zone.
+func (s Subscription) InQuietHours(now time.Time, def *time.Location) bool {
+	if s.Quiet == nil {
+		return false
+	}
+	loc := def
+	if s.TZ != "" {
+		if l, err := time.LoadLocation(s.TZ); err == nil {
+			loc = l
+		}
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return s.Quiet.Contains(now.In(loc))
+}
+
+// Coordinates returns the subscription location and whether it is set.
+func (s Subscription) Coordinates() (lat, lon float64, ok bool) {
+	if s.Lat == nil || s.Lon == nil {
+		return 0, 0, false
+	}
+	return *s.Lat, *s.Lon, true
+}
+
+// ValidateCoordinates checks that lat/lon are finite and within WGS84 ranges.
+func ValidateCoordinates(lat, lon float64) error {
+	if math.IsNaN(lat) || math.IsNaN(lon) || math.IsInf(lat, 0) || math.IsInf(lon, 0) {
+		return fmt.Errorf("coordinates must be finite numbers")
+	}
+	if lat < -90 || lat > 90 {
+		return fmt.Errorf("latitude %v out of range [-90, 90]", lat)
+	}
+	if lon < -180 || lon > 180 {
+		return fmt.Errorf("longitude %v out of range [-180, 180]", lon)
+	}
+	return nil
 }
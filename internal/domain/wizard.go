@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// Steps of the interactive /new schedule wizard.
+const (
+	WizardKind      = "kind"
+	WizardLocation  = "location"
+	WizardFrequency = "frequency"
+	// WizardTime waits for the daily time (HH:MM).
+	WizardTime = "time"
+	// WizardHours waits for the interval in hours.
+	WizardHours = "hours"
+	// WizardCron waits for a custom cron expression.
+	WizardCron = "cron"
+	// WizardEnd waits for the optional end date.
+	WizardEnd = "end"
+)
+
+// Wizard is the progress of a chat through the /new schedule wizard.
+type Wizard struct {
+	ChatID int64
+	Step   string
+	Kind   string
+	// LocationID is the chosen named location ("" means subscription coordinates).
+	LocationID string
+	Expr       string
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,203 @@
+package i18n
+
+var en = map[string]string{
+	"no_storage": "no storage configured",
+
+	"help.usage":   "usage: /help [command]",
+	"help.header":  "commands:",
+	"help.item":    "/%s — %s",
+	"help.footer":  "send /help <command> for the usage of one command",
+	"help.unknown": "unknown command /%s; send /help for the list of commands",
+
+	"cmd.start_scheduler": "subscribe this chat",
+	"cmd.stop_scheduler":  "unsubscribe and stop all schedules",
+	"cmd.new":             "create a schedule step by step",
+	"cmd.cancel":          "cancel the schedule wizard",
+	"cmd.start":           "create a schedule from a cron expression or a phrase",
+	"cmd.list_scheduler":  "list and manage schedules",
+	"cmd.stop":            "stop a schedule by ID",
+	"cmd.set_location":    "set the subscription location",
+	"cmd.location":        "manage named locations",
+	"cmd.template":        "customize message templates",
+	"cmd.language":        "change the language",
+	"cmd.units":           "change measurement units",
+	"cmd.quiet":           "set quiet hours",
+	"cmd.min_severity":    "set the minimum alert severity",
+	"cmd.usage":           "show weather API usage",
+	"cmd.help":            "list commands",
+
+	"subscription.started":     "scheduler successfully added. /help lists the available commands",
+	"subscription.stop_failed": "failed to stop scheduler",
+	"subscription.stopped":     "scheduler stopped",
+
+	"schedules.list_failed":        "failed to list schedulers",
+	"schedules.empty":              "no active schedulers",
+	"schedules.header":             "active schedulers:",
+	"schedules.item":               "%d. id: %s | location: %s | expr: %s | start_at: %s | end_at: %s",
+	"schedules.paused":             " | paused",
+	"schedules.legend":             "⏸ pause · ▶️ resume · ⏹ stop · ⚡ run now · 📜 history",
+	"schedules.not_found":          "schedule not found",
+	"schedules.action_failed":      "action failed, try again later",
+	"schedules.paused_done":        "schedule paused",
+	"schedules.resumed_done":       "schedule resumed",
+	"schedules.run_started":        "run started",
+	"schedules.stop_confirm":       "stop schedule %s? This cannot be undone.",
+	"schedules.button_stop":        "⏹ Stop",
+	"schedules.button_back":        "⬅️ Back",
+	"schedules.button_refresh":     "🔄 Refresh",
+	"schedules.history_header":     "last runs of %s:",
+	"schedules.history_empty":      "schedule %s has not run yet",
+	"schedules.history_item":       "%s — %s",
+	"schedules.status.success":     "ok",
+	"schedules.status.error":       "error",
+	"schedules.delivery.pending":   "sending",
+	"schedules.delivery.delivered": "delivered",
+	"schedules.delivery.failed":    "not delivered",
+
+	"start.usage":            "usage: /start [location=<name>] [kind=weather|air|nowcast] <cron expr|phrase> <start_at|-> <end_at|-> (times RFC3339)",
+	"start.failed":           "failed to create scheduler",
+	"start.created":          "scheduler created: %s",
+	"start.unknown_kind":     "unknown schedule kind: %s",
+	"start.invalid_schedule": "not a cron expression or a known schedule phrase: %s",
+	"start.interpreted":      "understood “%s” as %s",
+
+	"new.no_subscription":  "no active subscription; send /start_scheduler first",
+	"new.failed":           "failed to start the schedule wizard",
+	"new.cancelled":        "schedule wizard cancelled",
+	"new.expired":          "this wizard has expired, send /new to start again",
+	"new.ask_kind":         "new schedule: what should it send?",
+	"new.kind.weather":     "🌦 Weather alerts",
+	"new.kind.air":         "🌫 Air quality",
+	"new.kind.nowcast":     "☔ Rain in the next hour",
+	"new.ask_location":     "for which place?",
+	"new.location_default": "🏠 Subscription location",
+	"new.ask_frequency":    "how often should it run?",
+	"new.freq_daily":       "Every day at…",
+	"new.freq_hours":       "Every N hours",
+	"new.freq_cron":        "Custom cron expression",
+	"new.ask_time":         "at what time? Pick one or send HH:MM (time zone %s)",
+	"new.ask_hours":        "every how many hours? Pick one or send a number from 1 to 23",
+	"new.ask_cron":         "send a cron expression, e.g. 0 30 7 * * 1-5 (sec min hour day month weekday)",
+	"new.ask_end":          "schedule: %s. When should it end? Pick one or send a date YYYY-MM-DD",
+	"new.end_never":        "Never",
+	"new.end_week":         "In a week",
+	"new.end_month":        "In a month",
+	"new.button_cancel":    "✖️ Cancel",
+	"new.invalid_time":     "invalid time, send HH:MM, e.g. 07:30",
+	"new.invalid_hours":    "send a number of hours from 1 to 23",
+	"new.invalid_cron":     "invalid cron expression: %s",
+	"new.invalid_end":      "send a future date as YYYY-MM-DD, or - for no end",
+	"new.created":          "schedule created: %s\nexpr: %s | end_at: %s",
+
+	"stop.usage":  "usage: /stop <scheduler_id>",
+	"stop.failed": "failed to stop scheduler",
+	"stop.done":   "scheduler stopped",
+
+	"location.usage":          "usage: /set_location <lat> <lon> | <city>",
+	"location.invalid":        "invalid coordinates; usage: /set_location <lat> <lon> | <city>",
+	"location.out_of_range":   "invalid coordinates: latitude must be within -90..90 and longitude within -180..180",
+	"location.failed":         "failed to set location",
+	"location.set":            "location set: lat=%v lon=%v",
+	"location.set_place":      "location set: %s (lat=%v lon=%v)",
+	"location.geocode_failed": "failed to look up the place, try again later",
+	"location.not_found":      "no places found for %q",
+	"location.choose":         "several places match, choose one:",
+	"location.option":         "%d. %s — %s %s %s",
+
+	"locations.usage":         "usage: /location add <name> <lat> <lon> | /location add <name> <city> | /location list | /location remove <name>",
+	"locations.added":         "location %s saved: lat=%v lon=%v",
+	"locations.add_failed":    "failed to save location",
+	"locations.list_failed":   "failed to list locations",
+	"locations.empty":         "no locations; add one via /location add <name> <lat> <lon>",
+	"locations.header":        "locations:",
+	"locations.item":          "- %s: %s %s",
+	"locations.not_found":     "no location named %q",
+	"locations.in_use":        "location %q is used by active schedules; stop them first",
+	"locations.remove_failed": "failed to remove location",
+	"locations.removed":       "location %s removed",
+
+	"template.usage":        "usage: /template [show <name> | set <name> <body> | reset <name> | mode <html|markdown|plain>]",
+	"template.header":       "templates:",
+	"template.invalid":      "invalid template: %v",
+	"template.set_failed":   "failed to set template",
+	"template.updated":      "template %s updated",
+	"template.reset_failed": "failed to reset template",
+	"template.reset":        "template %s reset to default",
+	"template.mode_failed":  "failed to set parse mode",
+	"template.mode_set":     "parse mode set: %s",
+
+	"language.usage":  "usage: /language <en|ru|lt>",
+	"language.failed": "failed to set language",
+	"language.set":    "language set: English",
+
+	"units.usage":  "usage: /units <metric|imperial|standard>",
+	"units.failed": "failed to set units",
+	"units.set":    "units set: %s",
+
+	"quiet.usage":   "usage: /quiet <HH:MM-HH:MM> [time zone] | off (e.g. /quiet 22:00-07:00 Europe/Vilnius)",
+	"quiet.failed":  "failed to update quiet hours",
+	"quiet.bad_tz":  "unknown time zone: %s",
+	"quiet.set":     "quiet hours set: %s; only urgent messages are delivered meanwhile",
+	"quiet.current": "quiet hours: %s (%s)",
+	"quiet.off":     "quiet hours are off",
+
+	"min_severity.usage":   "usage: /min_severity <info|minor|moderate|severe|extreme> (info delivers all alerts)",
+	"min_severity.failed":  "failed to update minimum severity",
+	"min_severity.set":     "minimum alert severity set: %s",
+	"min_severity.current": "minimum alert severity: %s",
+
+	"usage.header":   "weather API usage:",
+	"usage.day":      "today (%s, all chats): %d/%s",
+	"usage.month":    "month (%s, all chats): %d/%s",
+	"usage.chat":     "this chat today: %d/%s",
+	"usage.degraded": "budget is running low: quiet places are refreshed at most every %v",
+	"usage.failed":   "failed to load usage",
+
+	"budget.day":       "daily",
+	"budget.month":     "monthly",
+	"budget.threshold": "⚠️ OpenWeather %s budget at %d%%: %d/%d calls",
+	"budget.exhausted": "⛔ OpenWeather %s budget exhausted (%d%%): %d/%d calls; weather requests are paused until the period ends",
+
+	"alert.period":             "from %s to %s",
+	"alert.tags":               "Tags: %s",
+	"alert.severity":           "Severity: %s",
+	"alert.updated":            "Updated",
+	"alert.changes":            "Changes: %s",
+	"alert.ended":              "Ended",
+	"alert.since":              "since %s",
+	"alert.change.end":         "end %s → %s",
+	"alert.change.tags":        "tags %s → %s",
+	"alert.change.description": "description changed",
+	"alert.change.none":        "no significant changes",
+
+	"severity.info":     "info",
+	"severity.minor":    "minor",
+	"severity.moderate": "moderate",
+	"severity.severe":   "severe",
+	"severity.extreme":  "extreme",
+
+	"urgent":              "call your parents urgently",
+	"all_clear":           "dangerous weather is over, you can breathe out",
+	"wind":                "wind %s",
+	"unit.temp.metric":    "°C",
+	"unit.temp.imperial":  "°F",
+	"unit.temp.standard":  " K",
+	"unit.speed.metric":   "m/s",
+	"unit.speed.imperial": "mph",
+	"unit.speed.standard": "m/s",
+	"digest.title":        "Weather alerts",
+
+	"air.title":   "Air quality index %d of 5",
+	"air.level.1": "good",
+	"air.level.2": "fair",
+	"air.level.3": "moderate",
+	"air.level.4": "poor",
+	"air.level.5": "very poor",
+	"air.pm":      "PM2.5: %s µg/m³, PM10: %s µg/m³",
+	"air.clear":   "air quality is back to normal (index %d)",
+
+	"nowcast.starts":       "rain starting in ~%d min",
+	"nowcast.now":          "rain starting now",
+	"nowcast.lasting":      "lasting ~%d min",
+	"nowcast.lasting_open": "lasting at least %d min",
+}
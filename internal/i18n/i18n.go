@@ -0,0 +1,68 @@
+// Package i18n provides the message catalog for bot replies and weather output.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported languages.
+const (
+	English    = "en"
+	Russian    = "ru"
+	Lithuanian = "lt"
+)
+
+// fallback is used when a key is missing in the requested language.
+const fallback = English
+
+var catalogs = map[string]map[string]string{
+	English:    en,
+	Russian:    ru,
+	Lithuanian: lt,
+}
+
+var timeLayouts = map[string]string{
+	English:    "02 Jan 2006 15:04",
+	Russian:    "02.01.2006 15:04",
+	Lithuanian: "2006-01-02 15:04",
+}
+
+// Supported returns supported language codes in display order.
+func Supported() []string {
+	return []string{English, Russian, Lithuanian}
+}
+
+// Normalize maps user input such as "RU" or "en-US" to a supported language code.
+func Normalize(lang string) (string, bool) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	_, ok := catalogs[lang]
+	return lang, ok
+}
+
+// T returns the message for key in lang formatted with args.
+// Missing keys fall back to English, then to the key itself.
+func T(lang, key string, args ...any) string {
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		msg, ok = catalogs[fallback][key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// TimeLayout returns the date-time layout used in messages for lang.
+func TimeLayout(lang string) string {
+	if l, ok := timeLayouts[lang]; ok {
+		return l
+	}
+	return timeLayouts[fallback]
+}
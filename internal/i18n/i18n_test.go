@@ -0,0 +1,43 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// verb matches a formatting verb, skipping escaped percent signs.
+var verb = regexp.MustCompile(`%%|%[-+# 0-9.]*[a-zA-Z]`)
+
+func verbs(msg string) []string {
+	var out []string
+	for _, v := range verb.FindAllString(msg, -1) {
+		if v != "%%" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// TestCatalogsMatch checks that every catalog has the keys of the fallback catalog
+// with the same formatting verbs, so callers passing arguments work in every language.
+func TestCatalogsMatch(t *testing.T) {
+	for _, lang := range Supported() {
+		catalog := catalogs[lang]
+		for key, msg := range catalogs[fallback] {
+			got, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: missing %q", lang, key)
+				continue
+			}
+			if !slices.Equal(verbs(got), verbs(msg)) {
+				t.Errorf("%s: %q has verbs %q, want %q", lang, key, verbs(got), verbs(msg))
+			}
+		}
+		for key := range catalog {
+			if _, ok := catalogs[fallback][key]; !ok {
+				t.Errorf("%s: %q is not in the %s catalog", lang, key, fallback)
+			}
+		}
+	}
+}
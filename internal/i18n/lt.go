@@ -0,0 +1,203 @@
+package i18n
+
+var lt = map[string]string{
+	"no_storage": "saugykla nesukonfigūruota",
+
+	"help.usage":   "naudojimas: /help [komanda]",
+	"help.header":  "komandos:",
+	"help.item":    "/%s — %s",
+	"help.footer":  "/help <komanda> parodys, kaip naudoti komandą",
+	"help.unknown": "nežinoma komanda /%s; /help parodys komandų sąrašą",
+
+	"cmd.start_scheduler": "užsiprenumeruoti šį pokalbį",
+	"cmd.stop_scheduler":  "atsisakyti prenumeratos ir sustabdyti visus tvarkaraščius",
+	"cmd.new":             "sukurti tvarkaraštį žingsnis po žingsnio",
+	"cmd.cancel":          "atšaukti tvarkaraščio kūrimą",
+	"cmd.start":           "sukurti tvarkaraštį iš cron išraiškos ar frazės",
+	"cmd.list_scheduler":  "tvarkaraščių sąrašas ir valdymas",
+	"cmd.stop":            "sustabdyti tvarkaraštį pagal ID",
+	"cmd.set_location":    "nustatyti prenumeratos vietą",
+	"cmd.location":        "valdyti pavadintas vietas",
+	"cmd.template":        "pritaikyti žinučių šablonus",
+	"cmd.language":        "pakeisti kalbą",
+	"cmd.units":           "pakeisti matavimo vienetus",
+	"cmd.quiet":           "nustatyti tylos valandas",
+	"cmd.min_severity":    "nustatyti mažiausią įspėjimų svarbą",
+	"cmd.usage":           "rodyti orų API naudojimą",
+	"cmd.help":            "komandų sąrašas",
+
+	"subscription.started":     "prenumerata įjungta. /help parodys galimas komandas",
+	"subscription.stop_failed": "nepavyko sustabdyti prenumeratos",
+	"subscription.stopped":     "prenumerata sustabdyta",
+
+	"schedules.list_failed":        "nepavyko gauti tvarkaraščių sąrašo",
+	"schedules.empty":              "aktyvių tvarkaraščių nėra",
+	"schedules.header":             "aktyvūs tvarkaraščiai:",
+	"schedules.item":               "%d. id: %s | vieta: %s | išraiška: %s | pradžia: %s | pabaiga: %s",
+	"schedules.paused":             " | pristabdytas",
+	"schedules.legend":             "⏸ pristabdyti · ▶️ tęsti · ⏹ sustabdyti · ⚡ paleisti dabar · 📜 istorija",
+	"schedules.not_found":          "tvarkaraštis nerastas",
+	"schedules.action_failed":      "veiksmas nepavyko, bandykite vėliau",
+	"schedules.paused_done":        "tvarkaraštis pristabdytas",
+	"schedules.resumed_done":       "tvarkaraštis pratęstas",
+	"schedules.run_started":        "paleidimas pradėtas",
+	"schedules.stop_confirm":       "sustabdyti tvarkaraštį %s? To nebus galima atšaukti.",
+	"schedules.button_stop":        "⏹ Sustabdyti",
+	"schedules.button_back":        "⬅️ Atgal",
+	"schedules.button_refresh":     "🔄 Atnaujinti",
+	"schedules.history_header":     "paskutiniai %s paleidimai:",
+	"schedules.history_empty":      "tvarkaraštis %s dar nebuvo paleistas",
+	"schedules.history_item":       "%s — %s",
+	"schedules.status.success":     "sėkmingai",
+	"schedules.status.error":       "klaida",
+	"schedules.delivery.pending":   "siunčiama",
+	"schedules.delivery.delivered": "pristatyta",
+	"schedules.delivery.failed":    "nepristatyta",
+
+	"start.usage":            "naudojimas: /start [location=<pavadinimas>] [kind=weather|air|nowcast] <cron išraiška|frazė> <pradžia|-> <pabaiga|-> (laikas RFC3339)",
+	"start.failed":           "nepavyko sukurti tvarkaraščio",
+	"start.created":          "tvarkaraštis sukurtas: %s",
+	"start.unknown_kind":     "nežinomas tvarkaraščio tipas: %s",
+	"start.invalid_schedule": "tai nei cron išraiška, nei žinoma tvarkaraščio frazė: %s",
+	"start.interpreted":      "„%s“ suprasta kaip %s",
+
+	"new.no_subscription":  "nėra aktyvios prenumeratos; pirmiausia išsiųskite /start_scheduler",
+	"new.failed":           "nepavyko paleisti tvarkaraščio vedlio",
+	"new.cancelled":        "tvarkaraščio kūrimas atšauktas",
+	"new.expired":          "vedlys nebegalioja, išsiųskite /new ir pradėkite iš naujo",
+	"new.ask_kind":         "naujas tvarkaraštis: ką siųsti?",
+	"new.kind.weather":     "🌦 Orų įspėjimai",
+	"new.kind.air":         "🌫 Oro kokybė",
+	"new.kind.nowcast":     "☔ Lietus per artimiausią valandą",
+	"new.ask_location":     "kuriai vietai?",
+	"new.location_default": "🏠 Prenumeratos vieta",
+	"new.ask_frequency":    "kaip dažnai vykdyti?",
+	"new.freq_daily":       "Kasdien…",
+	"new.freq_hours":       "Kas N valandų",
+	"new.freq_cron":        "Sava cron išraiška",
+	"new.ask_time":         "kurią valandą? Pasirinkite arba išsiųskite HH:MM (laiko juosta %s)",
+	"new.ask_hours":        "kas kiek valandų? Pasirinkite arba išsiųskite skaičių nuo 1 iki 23",
+	"new.ask_cron":         "išsiųskite cron išraišką, pvz. 0 30 7 * * 1-5 (sek min val diena mėnuo savaitės_diena)",
+	"new.ask_end":          "tvarkaraštis: %s. Kada baigti? Pasirinkite arba išsiųskite datą YYYY-MM-DD",
+	"new.end_never":        "Niekada",
+	"new.end_week":         "Po savaitės",
+	"new.end_month":        "Po mėnesio",
+	"new.button_cancel":    "✖️ Atšaukti",
+	"new.invalid_time":     "neteisingas laikas, išsiųskite HH:MM, pvz. 07:30",
+	"new.invalid_hours":    "išsiųskite valandų skaičių nuo 1 iki 23",
+	"new.invalid_cron":     "neteisinga cron išraiška: %s",
+	"new.invalid_end":      "išsiųskite būsimą datą YYYY-MM-DD arba - be pabaigos",
+	"new.created":          "tvarkaraštis sukurtas: %s\nišraiška: %s | pabaiga: %s",
+
+	"stop.usage":  "naudojimas: /stop <tvarkaraščio id>",
+	"stop.failed": "nepavyko sustabdyti tvarkaraščio",
+	"stop.done":   "tvarkaraštis sustabdytas",
+
+	"location.usage":          "naudojimas: /set_location <platuma> <ilguma> | <miestas>",
+	"location.invalid":        "neteisingos koordinatės; naudojimas: /set_location <platuma> <ilguma> | <miestas>",
+	"location.out_of_range":   "neteisingos koordinatės: platuma turi būti tarp -90..90, ilguma — tarp -180..180",
+	"location.failed":         "nepavyko išsaugoti vietos",
+	"location.set":            "vieta išsaugota: platuma=%v ilguma=%v",
+	"location.set_place":      "vieta išsaugota: %s (platuma=%v ilguma=%v)",
+	"location.geocode_failed": "nepavyko rasti vietos, bandykite vėliau",
+	"location.not_found":      "vieta %q nerasta",
+	"location.choose":         "rastos kelios vietos, pasirinkite vieną:",
+	"location.option":         "%d. %s — %s %s %s",
+
+	"locations.usage":         "naudojimas: /location add <pavadinimas> <platuma> <ilguma> | /location add <pavadinimas> <miestas> | /location list | /location remove <pavadinimas>",
+	"locations.added":         "vieta %s išsaugota: platuma=%v ilguma=%v",
+	"locations.add_failed":    "nepavyko išsaugoti vietos",
+	"locations.list_failed":   "nepavyko gauti vietų sąrašo",
+	"locations.empty":         "vietų nėra; pridėkite per /location add <pavadinimas> <platuma> <ilguma>",
+	"locations.header":        "vietos:",
+	"locations.item":          "- %s: %s %s",
+	"locations.not_found":     "vieta %q nerasta",
+	"locations.in_use":        "vieta %q naudojama aktyviuose tvarkaraščiuose; pirmiausia juos sustabdykite",
+	"locations.remove_failed": "nepavyko pašalinti vietos",
+	"locations.removed":       "vieta %s pašalinta",
+
+	"template.usage":        "naudojimas: /template [show <pavadinimas> | set <pavadinimas> <tekstas> | reset <pavadinimas> | mode <html|markdown|plain>]",
+	"template.header":       "šablonai:",
+	"template.invalid":      "neteisingas šablonas: %v",
+	"template.set_failed":   "nepavyko išsaugoti šablono",
+	"template.updated":      "šablonas %s atnaujintas",
+	"template.reset_failed": "nepavyko atstatyti šablono",
+	"template.reset":        "šablonas %s atstatytas",
+	"template.mode_failed":  "nepavyko išsaugoti formatavimo režimo",
+	"template.mode_set":     "formatavimo režimas: %s",
+
+	"language.usage":  "naudojimas: /language <en|ru|lt>",
+	"language.failed": "nepavyko pakeisti kalbos",
+	"language.set":    "kalba: lietuvių",
+
+	"units.usage":  "naudojimas: /units <metric|imperial|standard>",
+	"units.failed": "nepavyko pakeisti matavimo vienetų",
+	"units.set":    "matavimo vienetai: %s",
+
+	"quiet.usage":   "naudojimas: /quiet <HH:MM-HH:MM> [laiko juosta] | off (pvz., /quiet 22:00-07:00 Europe/Vilnius)",
+	"quiet.failed":  "nepavyko pakeisti tylos valandų",
+	"quiet.bad_tz":  "nežinoma laiko juosta: %s",
+	"quiet.set":     "tylos valandos: %s; tuo metu siunčiami tik skubūs pranešimai",
+	"quiet.current": "tylos valandos: %s (%s)",
+	"quiet.off":     "tylos valandos išjungtos",
+
+	"min_severity.usage":   "naudojimas: /min_severity <info|minor|moderate|severe|extreme> (info – visi įspėjimai)",
+	"min_severity.failed":  "nepavyko pakeisti minimalaus pavojingumo",
+	"min_severity.set":     "minimalus įspėjimų pavojingumas: %s",
+	"min_severity.current": "minimalus įspėjimų pavojingumas: %s",
+
+	"usage.header":   "orų API naudojimas:",
+	"usage.day":      "šiandien (%s, visi pokalbiai): %d/%s",
+	"usage.month":    "mėnuo (%s, visi pokalbiai): %d/%s",
+	"usage.chat":     "šis pokalbis šiandien: %d/%s",
+	"usage.degraded": "biudžetas baigiasi: ramios vietos atnaujinamos ne dažniau kaip kas %v",
+	"usage.failed":   "nepavyko gauti statistikos",
+
+	"budget.day":       "dienos",
+	"budget.month":     "mėnesio",
+	"budget.threshold": "⚠️ OpenWeather %s biudžetas išnaudotas %d%%: %d/%d užklausų",
+	"budget.exhausted": "⛔ OpenWeather %s biudžetas išnaudotas (%d%%): %d/%d užklausų; orų užklausos sustabdytos iki laikotarpio pabaigos",
+
+	"alert.period":             "nuo %s iki %s",
+	"alert.tags":               "Žymės: %s",
+	"alert.severity":           "Pavojingumas: %s",
+	"alert.updated":            "Atnaujinta",
+	"alert.changes":            "Pakeitimai: %s",
+	"alert.ended":              "Baigėsi",
+	"alert.since":              "nuo %s",
+	"alert.change.end":         "pabaiga %s → %s",
+	"alert.change.tags":        "žymės %s → %s",
+	"alert.change.description": "aprašymas pakeistas",
+	"alert.change.none":        "be esminių pakeitimų",
+
+	"severity.info":     "informacija",
+	"severity.minor":    "maža",
+	"severity.moderate": "vidutinė",
+	"severity.severe":   "didelė",
+	"severity.extreme":  "ekstremali",
+
+	"urgent":              "skubiai paskambink tėvams",
+	"all_clear":           "pavojingi orai baigėsi, galima atsikvėpti",
+	"wind":                "vėjas %s",
+	"unit.temp.metric":    "°C",
+	"unit.temp.imperial":  "°F",
+	"unit.temp.standard":  " K",
+	"unit.speed.metric":   "m/s",
+	"unit.speed.imperial": "mi/h",
+	"unit.speed.standard": "m/s",
+	"digest.title":        "Orų įspėjimai",
+
+	"air.title":   "Oro kokybės indeksas %d iš 5",
+	"air.level.1": "gera",
+	"air.level.2": "patenkinama",
+	"air.level.3": "vidutinė",
+	"air.level.4": "bloga",
+	"air.level.5": "labai bloga",
+	"air.pm":      "KD2,5: %s µg/m³, KD10: %s µg/m³",
+	"air.clear":   "oro kokybė vėl normali (indeksas %d)",
+
+	"nowcast.starts":       "lietus prasidės po ~%d min.",
+	"nowcast.now":          "prasideda lietus",
+	"nowcast.lasting":      "truks ~%d min.",
+	"nowcast.lasting_open": "truks mažiausiai %d min.",
+}
@@ -0,0 +1,203 @@
+package i18n
+
+var ru = map[string]string{
+	"no_storage": "хранилище не настроено",
+
+	"help.usage":   "использование: /help [команда]",
+	"help.header":  "команды:",
+	"help.item":    "/%s — %s",
+	"help.footer":  "/help <команда> покажет, как пользоваться командой",
+	"help.unknown": "неизвестная команда /%s; /help покажет список команд",
+
+	"cmd.start_scheduler": "подписать этот чат",
+	"cmd.stop_scheduler":  "отписаться и остановить все расписания",
+	"cmd.new":             "создать расписание по шагам",
+	"cmd.cancel":          "отменить создание расписания",
+	"cmd.start":           "создать расписание из cron-выражения или фразы",
+	"cmd.list_scheduler":  "список расписаний и управление ими",
+	"cmd.stop":            "остановить расписание по ID",
+	"cmd.set_location":    "задать место подписки",
+	"cmd.location":        "именованные места",
+	"cmd.template":        "настроить шаблоны сообщений",
+	"cmd.language":        "сменить язык",
+	"cmd.units":           "сменить единицы измерения",
+	"cmd.quiet":           "задать тихие часы",
+	"cmd.min_severity":    "задать минимальную важность предупреждений",
+	"cmd.usage":           "показать расход запросов к API погоды",
+	"cmd.help":            "список команд",
+
+	"subscription.started":     "подписка включена. /help покажет доступные команды",
+	"subscription.stop_failed": "не удалось остановить подписку",
+	"subscription.stopped":     "подписка остановлена",
+
+	"schedules.list_failed":        "не удалось получить список расписаний",
+	"schedules.empty":              "нет активных расписаний",
+	"schedules.header":             "активные расписания:",
+	"schedules.item":               "%d. id: %s | место: %s | выражение: %s | начало: %s | конец: %s",
+	"schedules.paused":             " | на паузе",
+	"schedules.legend":             "⏸ пауза · ▶️ продолжить · ⏹ остановить · ⚡ запустить сейчас · 📜 история",
+	"schedules.not_found":          "расписание не найдено",
+	"schedules.action_failed":      "не удалось выполнить действие, попробуйте позже",
+	"schedules.paused_done":        "расписание приостановлено",
+	"schedules.resumed_done":       "расписание возобновлено",
+	"schedules.run_started":        "запуск начат",
+	"schedules.stop_confirm":       "остановить расписание %s? Это действие нельзя отменить.",
+	"schedules.button_stop":        "⏹ Остановить",
+	"schedules.button_back":        "⬅️ Назад",
+	"schedules.button_refresh":     "🔄 Обновить",
+	"schedules.history_header":     "последние запуски %s:",
+	"schedules.history_empty":      "расписание %s ещё не запускалось",
+	"schedules.history_item":       "%s — %s",
+	"schedules.status.success":     "успешно",
+	"schedules.status.error":       "ошибка",
+	"schedules.delivery.pending":   "отправляется",
+	"schedules.delivery.delivered": "доставлено",
+	"schedules.delivery.failed":    "не доставлено",
+
+	"start.usage":            "использование: /start [location=<имя>] [kind=weather|air|nowcast] <cron выражение|фраза> <начало|-> <конец|-> (время в RFC3339)",
+	"start.failed":           "не удалось создать расписание",
+	"start.created":          "расписание создано: %s",
+	"start.unknown_kind":     "неизвестный тип расписания: %s",
+	"start.invalid_schedule": "не cron-выражение и не известная фраза расписания: %s",
+	"start.interpreted":      "«%s» распознано как %s",
+
+	"new.no_subscription":  "нет активной подписки; сначала отправьте /start_scheduler",
+	"new.failed":           "не удалось запустить мастер создания расписания",
+	"new.cancelled":        "создание расписания отменено",
+	"new.expired":          "мастер устарел, отправьте /new, чтобы начать заново",
+	"new.ask_kind":         "новое расписание: что присылать?",
+	"new.kind.weather":     "🌦 Погодные предупреждения",
+	"new.kind.air":         "🌫 Качество воздуха",
+	"new.kind.nowcast":     "☔ Дождь в ближайший час",
+	"new.ask_location":     "для какого места?",
+	"new.location_default": "🏠 Место подписки",
+	"new.ask_frequency":    "как часто запускать?",
+	"new.freq_daily":       "Каждый день в…",
+	"new.freq_hours":       "Каждые N часов",
+	"new.freq_cron":        "Своё cron-выражение",
+	"new.ask_time":         "в какое время? Выберите или отправьте ЧЧ:ММ (часовой пояс %s)",
+	"new.ask_hours":        "раз в сколько часов? Выберите или отправьте число от 1 до 23",
+	"new.ask_cron":         "отправьте cron-выражение, например 0 30 7 * * 1-5 (сек мин час день месяц день_недели)",
+	"new.ask_end":          "расписание: %s. Когда закончить? Выберите или отправьте дату ГГГГ-ММ-ДД",
+	"new.end_never":        "Никогда",
+	"new.end_week":         "Через неделю",
+	"new.end_month":        "Через месяц",
+	"new.button_cancel":    "✖️ Отмена",
+	"new.invalid_time":     "неверное время, отправьте ЧЧ:ММ, например 07:30",
+	"new.invalid_hours":    "отправьте число часов от 1 до 23",
+	"new.invalid_cron":     "неверное cron-выражение: %s",
+	"new.invalid_end":      "отправьте будущую дату в формате ГГГГ-ММ-ДД или - без окончания",
+	"new.created":          "расписание создано: %s\nвыражение: %s | конец: %s",
+
+	"stop.usage":  "использование: /stop <id расписания>",
+	"stop.failed": "не удалось остановить расписание",
+	"stop.done":   "расписание остановлено",
+
+	"location.usage":          "использование: /set_location <широта> <долгота> | <город>",
+	"location.invalid":        "неверные координаты; использование: /set_location <широта> <долгота> | <город>",
+	"location.out_of_range":   "неверные координаты: широта должна быть в диапазоне -90..90, долгота — -180..180",
+	"location.failed":         "не удалось сохранить координаты",
+	"location.set":            "координаты сохранены: широта=%v долгота=%v",
+	"location.set_place":      "координаты сохранены: %s (широта=%v долгота=%v)",
+	"location.geocode_failed": "не удалось найти место, попробуйте позже",
+	"location.not_found":      "место %q не найдено",
+	"location.choose":         "найдено несколько мест, выберите одно:",
+	"location.option":         "%d. %s — %s %s %s",
+
+	"locations.usage":         "использование: /location add <имя> <широта> <долгота> | /location add <имя> <город> | /location list | /location remove <имя>",
+	"locations.added":         "место %s сохранено: широта=%v долгота=%v",
+	"locations.add_failed":    "не удалось сохранить место",
+	"locations.list_failed":   "не удалось получить список мест",
+	"locations.empty":         "мест нет; добавьте через /location add <имя> <широта> <долгота>",
+	"locations.header":        "места:",
+	"locations.item":          "- %s: %s %s",
+	"locations.not_found":     "место %q не найдено",
+	"locations.in_use":        "место %q используется активными расписаниями; сначала остановите их",
+	"locations.remove_failed": "не удалось удалить место",
+	"locations.removed":       "место %s удалено",
+
+	"template.usage":        "использование: /template [show <имя> | set <имя> <текст> | reset <имя> | mode <html|markdown|plain>]",
+	"template.header":       "шаблоны:",
+	"template.invalid":      "неверный шаблон: %v",
+	"template.set_failed":   "не удалось сохранить шаблон",
+	"template.updated":      "шаблон %s обновлён",
+	"template.reset_failed": "не удалось сбросить шаблон",
+	"template.reset":        "шаблон %s сброшен",
+	"template.mode_failed":  "не удалось сохранить режим разметки",
+	"template.mode_set":     "режим разметки: %s",
+
+	"language.usage":  "использование: /language <en|ru|lt>",
+	"language.failed": "не удалось сменить язык",
+	"language.set":    "язык: русский",
+
+	"units.usage":  "использование: /units <metric|imperial|standard>",
+	"units.failed": "не удалось сменить единицы измерения",
+	"units.set":    "единицы измерения: %s",
+
+	"quiet.usage":   "использование: /quiet <ЧЧ:ММ-ЧЧ:ММ> [часовой пояс] | off (например, /quiet 22:00-07:00 Europe/Moscow)",
+	"quiet.failed":  "не удалось изменить тихие часы",
+	"quiet.bad_tz":  "неизвестный часовой пояс: %s",
+	"quiet.set":     "тихие часы: %s; в это время приходят только срочные сообщения",
+	"quiet.current": "тихие часы: %s (%s)",
+	"quiet.off":     "тихие часы выключены",
+
+	"min_severity.usage":   "использование: /min_severity <info|minor|moderate|severe|extreme> (info — все предупреждения)",
+	"min_severity.failed":  "не удалось изменить минимальную опасность",
+	"min_severity.set":     "минимальная опасность предупреждений: %s",
+	"min_severity.current": "минимальная опасность предупреждений: %s",
+
+	"usage.header":   "использование погодного API:",
+	"usage.day":      "сегодня (%s, все чаты): %d/%s",
+	"usage.month":    "месяц (%s, все чаты): %d/%s",
+	"usage.chat":     "этот чат сегодня: %d/%s",
+	"usage.degraded": "бюджет почти исчерпан: спокойные места обновляются не чаще раза в %v",
+	"usage.failed":   "не удалось получить статистику",
+
+	"budget.day":       "дневной",
+	"budget.month":     "месячный",
+	"budget.threshold": "⚠️ %s бюджет OpenWeather израсходован на %d%%: %d/%d запросов",
+	"budget.exhausted": "⛔ %s бюджет OpenWeather исчерпан (%d%%): %d/%d запросов; запросы погоды приостановлены до конца периода",
+
+	"alert.period":             "с %s до %s",
+	"alert.tags":               "Теги: %s",
+	"alert.severity":           "Опасность: %s",
+	"alert.updated":            "Обновлено",
+	"alert.changes":            "Изменения: %s",
+	"alert.ended":              "Завершено",
+	"alert.since":              "с %s",
+	"alert.change.end":         "окончание %s → %s",
+	"alert.change.tags":        "теги %s → %s",
+	"alert.change.description": "описание изменено",
+	"alert.change.none":        "без существенных изменений",
+
+	"severity.info":     "информация",
+	"severity.minor":    "низкая",
+	"severity.moderate": "средняя",
+	"severity.severe":   "высокая",
+	"severity.extreme":  "чрезвычайная",
+
+	"urgent":              "позвони срочно родителям",
+	"all_clear":           "опасная погода закончилась, можно выдохнуть",
+	"wind":                "ветер %s",
+	"unit.temp.metric":    "°C",
+	"unit.temp.imperial":  "°F",
+	"unit.temp.standard":  " K",
+	"unit.speed.metric":   "м/с",
+	"unit.speed.imperial": "миль/ч",
+	"unit.speed.standard": "м/с",
+	"digest.title":        "Погодные предупреждения",
+
+	"air.title":   "Индекс качества воздуха %d из 5",
+	"air.level.1": "хорошее",
+	"air.level.2": "удовлетворительное",
+	"air.level.3": "умеренное",
+	"air.level.4": "плохое",
+	"air.level.5": "очень плохое",
+	"air.pm":      "PM2.5: %s мкг/м³, PM10: %s мкг/м³",
+	"air.clear":   "качество воздуха снова в норме (индекс %d)",
+
+	"nowcast.starts":       "дождь начнётся через ~%d мин",
+	"nowcast.now":          "начинается дождь",
+	"nowcast.lasting":      "продлится ~%d мин",
+	"nowcast.lasting_open": "продлится не меньше %d мин",
+}
@@ -0,0 +1,329 @@
+// Package natural translates schedule phrases such as "every weekday at 7:30" or
+// "каждый день в 8:00" into expressions for the scheduler engine.
+//
+// The grammar, in English and Russian, is:
+//
+//	interval: every [N] (minute|hour)s            каждые [N] (минут|часов)
+//	times:    [days] at TIME [and TIME ...]         [days] в TIME [и TIME ...]
+//	days:     every day | daily                    каждый день | ежедневно
+//	          every weekday | on weekdays          по будням | каждый будний день
+//	          every weekend | on weekends          по выходным | в выходные
+//	          every monday [and friday ...]        каждый понедельник [и пятницу ...]
+//	          on the 1st [of every month]          1 числа [каждого месяца]
+//	TIME:     7 | 7:30 | 07:30 | 7am | 7:30 pm | noon | midnight
+//	          7 утра | 7 вечера | полдень | полночь
+//
+// Words may be separated by commas; case does not matter.
+package natural
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotUnderstood is returned for phrases outside the grammar.
+var ErrNotUnderstood = errors.New("schedule phrase not understood")
+
+// Schedule is a parsed phrase.
+type Schedule struct {
+	// Expr is a 6-field cron expression (with seconds) or an "@every <duration>" interval.
+	Expr string
+	// Every is the interval of "@every" schedules and 0 for cron expressions.
+	Every time.Duration
+}
+
+// word classes of the grammar
+var (
+	fillers = set("every", "each", "on", "the", "of", "day", "days", "month", "months",
+		"каждый", "каждую", "каждое", "каждые", "каждого", "каждой", "по",
+		"день", "дни", "месяц", "месяца", "числа")
+	dailyWords   = set("daily", "ежедневно")
+	weekdayWords = set("weekday", "weekdays", "будни", "будням", "будний", "будние", "рабочий", "рабочие", "рабочим")
+	weekendWords = set("weekend", "weekends", "выходные", "выходным", "выходной")
+	everyWords   = set("every", "each", "каждый", "каждую", "каждое", "каждые", "каждого", "каждой")
+	atWords      = set("at", "в", "во")
+	andWords     = set("and", "и")
+	minuteWords  = set("minute", "minutes", "min", "mins", "минута", "минуту", "минуты", "минут", "мин")
+	hourWords    = set("hour", "hours", "h", "hourly", "час", "часа", "часов", "ежечасно")
+	amWords      = set("am", "a.m.", "утра", "ночи")
+	pmWords      = set("pm", "p.m.", "вечера", "дня")
+)
+
+// dayNames maps weekday names and Russian stems to cron day numbers (Sunday is 0).
+var dayNames = []struct {
+	prefix string
+	day    int
+}{
+	{"mon", 1}, {"tue", 2}, {"wed", 3}, {"thu", 4}, {"fri", 5}, {"sat", 6}, {"sun", 0},
+	{"понедельник", 1}, {"вторник", 2}, {"сред", 3}, {"четверг", 4}, {"пятниц", 5}, {"суббот", 6}, {"воскресен", 0},
+}
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// Parse translates a phrase into a schedule or returns ErrNotUnderstood.
+func Parse(phrase string) (Schedule, error) {
+	tokens := strings.Fields(strings.ToLower(strings.NewReplacer(",", " ", ";", " ").Replace(phrase)))
+	if len(tokens) == 0 {
+		return Schedule{}, ErrNotUnderstood
+	}
+	if s, ok := parseInterval(tokens); ok {
+		return s, nil
+	}
+	return parseTimes(tokens)
+}
+
+// parseInterval handles "every [N] minutes|hours" and "hourly".
+func parseInterval(tokens []string) (Schedule, bool) {
+	if len(tokens) == 1 && (tokens[0] == "hourly" || tokens[0] == "ежечасно") {
+		return Schedule{Expr: "0 0 * * * *"}, true
+	}
+	if len(tokens) < 2 || len(tokens) > 3 || !everyWords[tokens[0]] {
+		return Schedule{}, false
+	}
+	n := 1
+	unit := tokens[1]
+	if len(tokens) == 3 {
+		v, err := strconv.Atoi(tokens[1])
+		if err != nil || v <= 0 {
+			return Schedule{}, false
+		}
+		n, unit = v, tokens[2]
+	}
+	var d time.Duration
+	switch {
+	case minuteWords[unit]:
+		d = time.Duration(n) * time.Minute
+	case hourWords[unit]:
+		d = time.Duration(n) * time.Hour
+	default:
+		return Schedule{}, false
+	}
+	// Whole hours and minutes that divide them stay aligned to the clock.
+	switch {
+	case d == time.Hour:
+		return Schedule{Expr: "0 0 * * * *"}, true
+	case d < time.Hour && 60%n == 0 && minuteWords[unit]:
+		return Schedule{Expr: fmt.Sprintf("0 */%d * * * *", n)}, true
+	case d < 24*time.Hour && 24%n == 0 && hourWords[unit]:
+		return Schedule{Expr: fmt.Sprintf("0 0 */%d * * *", n)}, true
+	}
+	return Schedule{Expr: "@every " + formatDuration(d), Every: d}, true
+}
+
+// parseTimes handles day specifications followed by one or more times of day.
+func parseTimes(tokens []string) (Schedule, error) {
+	var (
+		days   []int
+		dom    int
+		hours  []int
+		minute = -1
+		// wantTime is set after "at" or "and" following a time, where a bare number is an hour.
+		wantTime, afterTime bool
+	)
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if atWords[tok] || andWords[tok] {
+			wantTime = atWords[tok] || afterTime
+			afterTime = false
+			continue
+		}
+		expected := wantTime
+		wantTime, afterTime = false, false
+
+		switch {
+		case dailyWords[tok]:
+			continue
+		case weekdayWords[tok]:
+			days = append(days, 1, 2, 3, 4, 5)
+			continue
+		case weekendWords[tok]:
+			days = append(days, 6, 0)
+			continue
+		}
+		if d, ok := dayName(tok); ok {
+			days = append(days, d)
+			continue
+		}
+		if n, ok := dayOfMonth(tokens, i); ok && !expected {
+			dom = n
+			continue
+		}
+		period := i+1 < len(tokens) && (amWords[tokens[i+1]] || pmWords[tokens[i+1]])
+		if h, m, ok := parseTime(tok); ok && (expected || period || !isNumber(tok)) {
+			// "7 am", "8 утра": the period may be a separate word.
+			if period {
+				if h, ok = applyPeriod(h, pmWords[tokens[i+1]]); !ok {
+					return Schedule{}, ErrNotUnderstood
+				}
+				i++
+			}
+			if minute >= 0 && m != minute {
+				return Schedule{}, fmt.Errorf("%w: all times must have the same minutes", ErrNotUnderstood)
+			}
+			minute = m
+			hours = append(hours, h)
+			afterTime = true
+			continue
+		}
+		if fillers[tok] {
+			continue
+		}
+		return Schedule{}, ErrNotUnderstood
+	}
+	if len(hours) == 0 {
+		return Schedule{}, ErrNotUnderstood
+	}
+
+	slices.Sort(hours)
+	dowField := "*"
+	if len(days) > 0 {
+		slices.Sort(days)
+		dowField = compress(slices.Compact(days))
+	}
+	domField := "*"
+	if dom > 0 {
+		domField = strconv.Itoa(dom)
+	}
+	return Schedule{Expr: fmt.Sprintf("0 %d %s %s * %s", minute, joinInts(slices.Compact(hours)), domField, dowField)}, nil
+}
+
+// dayName matches English weekday names ("mon", "monday", "mondays") and Russian word forms.
+func dayName(tok string) (int, bool) {
+	for _, d := range dayNames {
+		if strings.HasPrefix(tok, d.prefix) && (len(d.prefix) > 3 || englishDay(tok)) {
+			return d.day, true
+		}
+	}
+	return 0, false
+}
+
+func englishDay(tok string) bool {
+	tok = strings.TrimSuffix(tok, "s")
+	switch tok {
+	case "mon", "monday", "tue", "tues", "tuesday", "wed", "wednesday", "thu", "thur", "thurs", "thursday",
+		"fri", "friday", "sat", "saturday", "sun", "sunday":
+		return true
+	}
+	return false
+}
+
+// dayOfMonth matches "1st", "15th" and a number followed by "числа".
+func dayOfMonth(tokens []string, i int) (int, bool) {
+	tok := tokens[i]
+	for _, suffix := range []string{"st", "nd", "rd", "th", "-го", "-е"} {
+		if v, ok := strings.CutSuffix(tok, suffix); ok {
+			tok = v
+			break
+		}
+	}
+	n, err := strconv.Atoi(tok)
+	if err != nil || n < 1 || n > 31 {
+		return 0, false
+	}
+	if tok != tokens[i] || (i+1 < len(tokens) && tokens[i+1] == "числа") {
+		return n, true
+	}
+	return 0, false
+}
+
+// parseTime parses "7", "7:30", "07.30", "7am", "7:30pm", "noon" and "midnight".
+func parseTime(tok string) (hour, minute int, ok bool) {
+	switch tok {
+	case "noon", "полдень":
+		return 12, 0, true
+	case "midnight", "полночь":
+		return 0, 0, true
+	}
+	pm, period := false, false
+	for _, suffix := range []string{"am", "pm"} {
+		if v, found := strings.CutSuffix(tok, suffix); found {
+			tok, pm, period = v, suffix == "pm", true
+			break
+		}
+	}
+	h, m, hasMinutes := strings.Cut(strings.ReplaceAll(tok, ".", ":"), ":")
+	hour, err := strconv.Atoi(h)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, false
+	}
+	if hasMinutes {
+		if len(m) != 2 {
+			return 0, 0, false
+		}
+		minute, err = strconv.Atoi(m)
+		if err != nil || minute < 0 || minute > 59 {
+			return 0, 0, false
+		}
+	}
+	if period {
+		if hour, ok = applyPeriod(hour, pm); !ok {
+			return 0, 0, false
+		}
+	}
+	return hour, minute, true
+}
+
+// applyPeriod converts a 12-hour clock hour to 24 hours.
+func applyPeriod(hour int, pm bool) (int, bool) {
+	if hour < 1 || hour > 12 {
+		return 0, false
+	}
+	if hour == 12 {
+		hour = 0
+	}
+	if pm {
+		hour += 12
+	}
+	return hour, true
+}
+
+func isNumber(tok string) bool {
+	_, err := strconv.Atoi(tok)
+	return err == nil
+}
+
+// compress writes sorted days as a cron list, joining runs into ranges ("1-5", "0,6").
+func compress(days []int) string {
+	var parts []string
+	for i := 0; i < len(days); {
+		j := i
+		for j+1 < len(days) && days[j+1] == days[j]+1 {
+			j++
+		}
+		if j-i >= 2 {
+			parts = append(parts, fmt.Sprintf("%d-%d", days[i], days[j]))
+		} else {
+			for k := i; k <= j; k++ {
+				parts = append(parts, strconv.Itoa(days[k]))
+			}
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+func joinInts(v []int) string {
+	s := make([]string, len(v))
+	for i, n := range v {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+// formatDuration prints d without zero units ("90m", "36h").
+func formatDuration(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
@@ -0,0 +1,77 @@
+package natural
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		phrase string
+		want   string
+	}{
+		{"every day at 8:00", "0 0 8 * * *"},
+		{"daily at 7am", "0 0 7 * * *"},
+		{"every weekday at 7:30", "0 30 7 * * 1-5"},
+		{"on weekends at 10", "0 0 10 * * 0,6"},
+		{"every Monday, Wednesday and Friday at 6:15 pm", "0 15 18 * * 1,3,5"},
+		{"every day at 8 and 20", "0 0 8,20 * * *"},
+		{"at noon", "0 0 12 * * *"},
+		{"on the 1st of every month at 9:00", "0 0 9 1 * *"},
+		{"every hour", "0 0 * * * *"},
+		{"every 15 minutes", "0 */15 * * * *"},
+		{"every 6 hours", "0 0 */6 * * *"},
+		{"every 90 minutes", "@every 90m"},
+		{"каждый день в 8:00", "0 0 8 * * *"},
+		{"ежедневно в 7 утра", "0 0 7 * * *"},
+		{"по будням в 7:30", "0 30 7 * * 1-5"},
+		{"в выходные в 10", "0 0 10 * * 0,6"},
+		{"каждый понедельник и пятницу в 9 вечера", "0 0 21 * * 1,5"},
+		{"1 числа каждого месяца в 9:00", "0 0 9 1 * *"},
+		{"каждые 2 часа", "0 0 */2 * * *"},
+		{"каждые 5 часов", "@every 5h"},
+		{"каждый час", "0 0 * * * *"},
+	}
+	parser := cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	for _, tt := range tests {
+		got, err := Parse(tt.phrase)
+		if err != nil {
+			t.Errorf("%q: %v", tt.phrase, err)
+			continue
+		}
+		if got.Expr != tt.want {
+			t.Errorf("%q: expr = %q, want %q", tt.phrase, got.Expr, tt.want)
+		}
+		if _, err := parser.Parse(got.Expr); err != nil {
+			t.Errorf("%q: engine rejects %q: %v", tt.phrase, got.Expr, err)
+		}
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	got, err := Parse("every 90 minutes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Every != 90*time.Minute {
+		t.Errorf("every = %v, want 1h30m", got.Every)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, phrase := range []string{
+		"",
+		"every day",
+		"tomorrow at 8",
+		"every day at 25:00",
+		"every day at 8:00 and 9:30",
+		"0 0 8 * *",
+	} {
+		if _, err := Parse(phrase); !errors.Is(err, ErrNotUnderstood) {
+			t.Errorf("%q: err = %v, want ErrNotUnderstood", phrase, err)
+		}
+	}
+}
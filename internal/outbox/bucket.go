@@ -0,0 +1,62 @@
+package outbox
+
+import "time"
+
+// bucket is a token bucket: it holds up to burst tokens and refills at rate tokens per second.
+// It is not safe for concurrent use.
+type bucket struct {
+	rate  float64
+	burst float64
+
+	tokens float64
+	last   time.Time
+	// blockedUntil pauses the bucket after a transport asked to retry later.
+	blockedUntil time.Time
+}
+
+func newBucket(rate, burst float64) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{rate: rate, burst: burst, tokens: burst}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+// delay returns how long to wait until a token is available (0 means now).
+func (b *bucket) delay(now time.Time) time.Duration {
+	b.refill(now)
+	var d time.Duration
+	if b.tokens < 1 && b.rate > 0 {
+		d = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	if wait := b.blockedUntil.Sub(now); wait > d {
+		d = wait
+	}
+	return d
+}
+
+// take consumes one token; call it after delay returned 0.
+func (b *bucket) take(now time.Time) {
+	b.refill(now)
+	b.tokens--
+}
+
+// block empties the bucket and pauses it until t.
+func (b *bucket) block(t time.Time) {
+	b.tokens = 0
+	if t.After(b.blockedUntil) {
+		b.blockedUntil = t
+	}
+}
+
+// idle reports whether the bucket is full and not blocked, so it can be dropped.
+func (b *bucket) idle(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.burst && !now.Before(b.blockedUntil)
+}
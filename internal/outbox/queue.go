@@ -0,0 +1,302 @@
+// Package outbox provides a persistent outbound message queue with Telegram rate limiting.
+//
+// Messages are stored in Postgres and delivered by a worker that respects a global and a
+// per-chat token bucket, honours retry_after from the transport and reports delivery state
+// of scheduled messages back to their runs.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/storage"
+	"cron-weather/internal/transport"
+)
+
+// Options configures delivery rates and retries.
+type Options struct {
+	// GlobalRate is the bot-wide message rate per second (Telegram allows about 30).
+	GlobalRate float64
+	// ChatRate is the message rate per second for one private chat (Telegram allows about 1).
+	ChatRate float64
+	// GroupRate is the message rate per second for one group chat (Telegram allows about 20 per minute).
+	GroupRate float64
+	// MaxAttempts is how many times a message is tried before it is marked failed.
+	MaxAttempts int
+	// PollInterval is how often the queue is checked for due messages.
+	PollInterval time.Duration
+	// Redact hides secrets in delivery errors before they are stored; it may be nil.
+	Redact func(string) string
+}
+
+const (
+	defaultGlobalRate   = 30
+	defaultChatRate     = 1
+	defaultGroupRate    = 20.0 / 60
+	defaultMaxAttempts  = 5
+	defaultPollInterval = 250 * time.Millisecond
+
+	// lease is how long a claimed message is hidden from other workers.
+	lease = time.Minute
+
+	baseBackoff = 2 * time.Second
+	maxBackoff  = 5 * time.Minute
+)
+
+// clock is the time source of the queue; tests replace it.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Queue is a transport.Producer that persists messages and delivers them in the background.
+type Queue struct {
+	log      *slog.Logger
+	repo     storage.Repo
+	producer transport.Producer
+	opts     Options
+
+	wake  chan struct{}
+	clock clock
+
+	mu     sync.Mutex
+	global *bucket
+	chats  map[int64]*bucket
+}
+
+// New constructs a queue delivering through producer. Call Run to start delivery.
+func New(log *slog.Logger, repo storage.Repo, producer transport.Producer, opts Options) *Queue {
+	if log == nil {
+		log = slog.Default()
+	}
+	if opts.GlobalRate <= 0 {
+		opts.GlobalRate = defaultGlobalRate
+	}
+	if opts.ChatRate <= 0 {
+		opts.ChatRate = defaultChatRate
+	}
+	if opts.GroupRate <= 0 {
+		opts.GroupRate = defaultGroupRate
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	return &Queue{
+		log:      log,
+		repo:     repo,
+		producer: producer,
+		opts:     opts,
+		wake:     make(chan struct{}, 1),
+		clock:    systemClock{},
+		global:   newBucket(opts.GlobalRate, opts.GlobalRate),
+		chats:    make(map[int64]*bucket),
+	}
+}
+
+// Send implements transport.Producer by storing the message for background delivery.
+// Text over the Telegram limit is stored as several messages, each sent and rate limited on its own;
+// the parts are stored together or not at all.
+func (q *Queue) Send(ctx context.Context, msg transport.Message) error {
+	parts := transport.SplitMessage(msg, transport.MaxMessageLength)
+	msgs := make([]domain.OutboxMessage, 0, len(parts))
+	for _, part := range parts {
+		var markup string
+		if len(part.Keyboard) > 0 {
+			b, err := json.Marshal(part.Keyboard)
+			if err != nil {
+				return fmt.Errorf("encode keyboard: %w", err)
+			}
+			markup = string(b)
+		}
+		msgs = append(msgs, domain.OutboxMessage{
+			RunID:         part.RunID,
+			ChatID:        part.ChatID,
+			Text:          part.Text,
+			ParseMode:     part.ParseMode,
+			ReplyMarkup:   markup,
+			EditMessageID: part.EditMessageID,
+		})
+	}
+	if err := q.repo.EnqueueMessages(ctx, msgs); err != nil {
+		return err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run delivers queued messages until ctx is cancelled.
+func (q *Queue) Run(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// drain delivers due messages until none are left.
+func (q *Queue) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := q.clock.Now()
+		msgs, err := q.repo.ClaimOutbox(ctx, now, int(q.opts.GlobalRate)+1, now.Add(lease))
+		if err != nil {
+			q.log.Error("failed to claim outbox messages", slog.Any("err", err))
+			return
+		}
+		if len(msgs) == 0 {
+			q.prune(now)
+			return
+		}
+		for _, m := range msgs {
+			q.deliver(ctx, m)
+		}
+	}
+}
+
+// deliver sends one claimed message, waiting for the global bucket.
+// A message whose chat bucket is empty is deferred instead of blocking other chats.
+func (q *Queue) deliver(ctx context.Context, m domain.OutboxMessage) {
+	now := q.clock.Now()
+	q.mu.Lock()
+	chat := q.chat(m.ChatID)
+	if d := chat.delay(now); d > 0 {
+		q.mu.Unlock()
+		q.check(m, q.repo.DeferOutbox(ctx, m.ID, now.Add(d)))
+		return
+	}
+	for {
+		d := q.global.delay(now)
+		if d == 0 {
+			break
+		}
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			// The lease expires and another run picks the message up.
+			return
+		case <-q.clock.After(d):
+		}
+		now = q.clock.Now()
+		q.mu.Lock()
+	}
+	q.global.take(now)
+	chat.take(now)
+	q.mu.Unlock()
+
+	msg := transport.Message{ChatID: m.ChatID, Text: m.Text, ParseMode: m.ParseMode, EditMessageID: m.EditMessageID}
+	var err error
+	if m.ReplyMarkup != "" {
+		err = json.Unmarshal([]byte(m.ReplyMarkup), &msg.Keyboard)
+	}
+	if err == nil {
+		err = q.producer.Send(ctx, msg)
+	}
+	if err == nil {
+		q.check(m, q.repo.CompleteOutbox(ctx, m.ID))
+		return
+	}
+
+	var se *transport.SendError
+	errors.As(err, &se)
+	attempt := m.Attempts + 1
+	switch {
+	case se != nil && se.Permanent, attempt >= q.opts.MaxAttempts:
+		q.log.Error("outbox message failed",
+			slog.Any("err", err),
+			slog.Int64("chat_id", m.ChatID),
+			slog.Int64("run_id", m.RunID),
+			slog.Int("attempts", attempt),
+		)
+		q.check(m, q.repo.FailOutbox(ctx, m.ID, q.errText(err)))
+	case se != nil && se.RetryAfter > 0:
+		until := q.clock.Now().Add(se.RetryAfter)
+		q.mu.Lock()
+		chat.block(until)
+		q.mu.Unlock()
+		q.log.Warn("telegram rate limit hit",
+			slog.Int64("chat_id", m.ChatID),
+			slog.Duration("retry_after", se.RetryAfter),
+		)
+		q.check(m, q.repo.RetryOutbox(ctx, m.ID, until, q.errText(err)))
+	default:
+		q.log.Warn("outbox message will be retried",
+			slog.Any("err", err),
+			slog.Int64("chat_id", m.ChatID),
+			slog.Int("attempts", attempt),
+		)
+		q.check(m, q.repo.RetryOutbox(ctx, m.ID, q.clock.Now().Add(backoff(attempt)), q.errText(err)))
+	}
+}
+
+// errText returns the error text to store, with secrets hidden.
+func (q *Queue) errText(err error) string {
+	if q.opts.Redact == nil {
+		return err.Error()
+	}
+	return q.opts.Redact(err.Error())
+}
+
+// check logs a failed queue state change; the message lease makes it retry later.
+func (q *Queue) check(m domain.OutboxMessage, err error) {
+	if err != nil {
+		q.log.Error("failed to update outbox message", slog.Any("err", err), slog.Int64("id", m.ID))
+	}
+}
+
+// chat returns the bucket of a chat; q.mu must be held.
+// Negative chat IDs are groups and channels, which Telegram limits per minute.
+func (q *Queue) chat(chatID int64) *bucket {
+	b, ok := q.chats[chatID]
+	if !ok {
+		rate := q.opts.ChatRate
+		if chatID < 0 {
+			rate = q.opts.GroupRate
+		}
+		b = newBucket(rate, 1)
+		q.chats[chatID] = b
+	}
+	return b
+}
+
+// prune drops buckets of chats that have been quiet long enough to refill.
+func (q *Queue) prune(now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for id, b := range q.chats {
+		if b.idle(now) {
+			delete(q.chats, id)
+		}
+	}
+}
+
+// backoff returns the delay before retry number attempt (1-based).
+func backoff(attempt int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
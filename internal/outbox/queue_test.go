@@ -0,0 +1,301 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/storage"
+	"cron-weather/internal/transport"
+)
+
+// fakeClock only moves when the queue waits.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// outboxRow is a queued message with the state the Postgres outbox keeps.
+type outboxRow struct {
+	domain.OutboxMessage
+	state     string
+	nextAt    time.Time
+	lastError string
+}
+
+// memRepo keeps the outbox and run delivery state in memory, like the Postgres queries do.
+// Methods the queue does not use panic through the nil embedded Repo.
+type memRepo struct {
+	storage.Repo
+
+	clock *fakeClock
+	rows  []*outboxRow
+	// runs holds the delivery state and error of every run with queued messages.
+	runs      map[int64]string
+	runErrors map[int64]string
+}
+
+func newMemRepo(clock *fakeClock) *memRepo {
+	return &memRepo{clock: clock, runs: map[int64]string{}, runErrors: map[int64]string{}}
+}
+
+func (r *memRepo) EnqueueMessages(_ context.Context, msgs []domain.OutboxMessage) error {
+	for _, m := range msgs {
+		m.ID = int64(len(r.rows) + 1)
+		r.rows = append(r.rows, &outboxRow{OutboxMessage: m, state: "pending", nextAt: r.clock.Now()})
+		if m.RunID != 0 && r.runs[m.RunID] == "" {
+			r.runs[m.RunID] = "pending"
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) ClaimOutbox(_ context.Context, now time.Time, limit int, leaseUntil time.Time) ([]domain.OutboxMessage, error) {
+	var out []domain.OutboxMessage
+	seen := map[int64]bool{}
+	for _, row := range r.rows {
+		if row.state != "pending" || seen[row.ChatID] {
+			continue
+		}
+		// Only the oldest pending message of a chat is eligible.
+		seen[row.ChatID] = true
+		if row.nextAt.After(now) || len(out) == limit {
+			continue
+		}
+		row.nextAt = leaseUntil
+		out = append(out, row.OutboxMessage)
+	}
+	return out, nil
+}
+
+func (r *memRepo) row(id int64) *outboxRow { return r.rows[id-1] }
+
+func (r *memRepo) DeferOutbox(_ context.Context, id int64, at time.Time) error {
+	r.row(id).nextAt = at
+	return nil
+}
+
+func (r *memRepo) RetryOutbox(_ context.Context, id int64, at time.Time, errText string) error {
+	row := r.row(id)
+	row.Attempts++
+	row.nextAt, row.lastError = at, errText
+	return nil
+}
+
+func (r *memRepo) CompleteOutbox(_ context.Context, id int64) error {
+	row := r.row(id)
+	row.state = "delivered"
+	if row.RunID == 0 || r.runs[row.RunID] != "pending" {
+		return nil
+	}
+	for _, o := range r.rows {
+		if o.RunID == row.RunID && o.state == "pending" {
+			return nil
+		}
+	}
+	r.runs[row.RunID] = "delivered"
+	return nil
+}
+
+func (r *memRepo) FailOutbox(_ context.Context, id int64, errText string) error {
+	row := r.row(id)
+	row.Attempts++
+	row.state, row.lastError = "failed", errText
+	if row.RunID != 0 {
+		r.runs[row.RunID], r.runErrors[row.RunID] = "failed", errText
+	}
+	return nil
+}
+
+// sent is a message delivered by fakeSender and the fake time it was delivered at.
+type sent struct {
+	text string
+	at   time.Duration
+}
+
+// fakeSender records deliveries; errs are returned by the next calls, one per call.
+type fakeSender struct {
+	clock *fakeClock
+	start time.Time
+	errs  []error
+	sent  []sent
+}
+
+func (s *fakeSender) Send(_ context.Context, msg transport.Message) error {
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	s.sent = append(s.sent, sent{text: msg.Text, at: s.clock.Now().Sub(s.start)})
+	return nil
+}
+
+func newTestQueue(opts Options, errs ...error) (*Queue, *memRepo, *fakeSender, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	repo := newMemRepo(clock)
+	sender := &fakeSender{clock: clock, start: clock.now, errs: errs}
+	q := New(nil, repo, sender, opts)
+	q.clock = clock
+	return q, repo, sender, clock
+}
+
+func send(t *testing.T, q *Queue, msgs ...transport.Message) {
+	t.Helper()
+	for _, m := range msgs {
+		if err := q.Send(context.Background(), m); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+}
+
+func TestQueueGlobalBucket(t *testing.T) {
+	q, _, sender, _ := newTestQueue(Options{GlobalRate: 1})
+	send(t, q,
+		transport.Message{ChatID: 1, Text: "a"},
+		transport.Message{ChatID: 2, Text: "b"},
+		transport.Message{ChatID: 3, Text: "c"},
+	)
+	q.drain(context.Background())
+
+	want := []sent{{"a", 0}, {"b", time.Second}, {"c", 2 * time.Second}}
+	if !slices.Equal(sender.sent, want) {
+		t.Errorf("sent = %v, want %v", sender.sent, want)
+	}
+}
+
+func TestQueueChatBucket(t *testing.T) {
+	q, _, sender, clock := newTestQueue(Options{ChatRate: 1, GroupRate: 0.5})
+	send(t, q,
+		transport.Message{ChatID: 1, Text: "private 1"},
+		transport.Message{ChatID: 1, Text: "private 2"},
+		transport.Message{ChatID: -1, Text: "group 1"},
+		transport.Message{ChatID: -1, Text: "group 2"},
+		transport.Message{ChatID: 2, Text: "other chat"},
+	)
+
+	// A chat without tokens is deferred and does not hold back the others.
+	q.drain(context.Background())
+	if got := texts(sender.sent); !slices.Equal(got, []string{"private 1", "group 1", "other chat"}) {
+		t.Fatalf("first drain sent %q", got)
+	}
+
+	clock.advance(time.Second)
+	q.drain(context.Background())
+	if got := texts(sender.sent[3:]); !slices.Equal(got, []string{"private 2"}) {
+		t.Fatalf("after 1s sent %q, want the private chat only", got)
+	}
+
+	clock.advance(time.Second)
+	q.drain(context.Background())
+	if got := texts(sender.sent[4:]); !slices.Equal(got, []string{"group 2"}) {
+		t.Fatalf("after 2s sent %q, want the group chat", got)
+	}
+}
+
+func TestQueueRetryAfter(t *testing.T) {
+	limited := &transport.SendError{Err: errors.New("429 Too Many Requests"), RetryAfter: 30 * time.Second}
+	q, repo, sender, clock := newTestQueue(Options{}, limited)
+	send(t, q, transport.Message{ChatID: 1, Text: "a", RunID: 7})
+
+	q.drain(context.Background())
+	row := repo.row(1)
+	if len(sender.sent) != 0 || row.Attempts != 1 || row.nextAt.Sub(clock.Now()) != 30*time.Second {
+		t.Fatalf("after 429: sent %v, attempts %d, next in %v", sender.sent, row.Attempts, row.nextAt.Sub(clock.Now()))
+	}
+
+	clock.advance(10 * time.Second)
+	q.drain(context.Background())
+	if len(sender.sent) != 0 {
+		t.Fatalf("sent %v before retry_after passed", sender.sent)
+	}
+
+	clock.advance(20 * time.Second)
+	q.drain(context.Background())
+	if len(sender.sent) != 1 || repo.runs[7] != "delivered" {
+		t.Errorf("after retry_after: sent %v, run delivery %q", sender.sent, repo.runs[7])
+	}
+}
+
+func TestQueueRunDelivery(t *testing.T) {
+	permanent := &transport.SendError{Err: errors.New(`Post "https://api.telegram.org/bot123:SECRET/sendMessage": forbidden`), Permanent: true}
+	q, repo, _, _ := newTestQueue(Options{Redact: func(s string) string { return strings.ReplaceAll(s, "123:SECRET", "***") }},
+		nil, nil, permanent)
+
+	// Run 1 has two messages and is delivered only after both; run 2 fails for good.
+	send(t, q,
+		transport.Message{ChatID: 1, Text: "a", RunID: 1},
+		transport.Message{ChatID: 2, Text: "b", RunID: 1},
+		transport.Message{ChatID: 3, Text: "c", RunID: 2},
+	)
+	if repo.runs[1] != "pending" || repo.runs[2] != "pending" {
+		t.Fatalf("runs after enqueue = %v, want pending", repo.runs)
+	}
+	q.drain(context.Background())
+
+	if repo.runs[1] != "delivered" {
+		t.Errorf("run 1 delivery = %q, want delivered", repo.runs[1])
+	}
+	if repo.runs[2] != "failed" {
+		t.Errorf("run 2 delivery = %q, want failed", repo.runs[2])
+	}
+	if e := repo.runErrors[2]; strings.Contains(e, "SECRET") || !strings.Contains(e, "forbidden") {
+		t.Errorf("run 2 delivery error = %q, want it redacted", e)
+	}
+}
+
+func TestQueueGivesUpAfterMaxAttempts(t *testing.T) {
+	transient := errors.New("connection reset")
+	q, repo, sender, clock := newTestQueue(Options{MaxAttempts: 3}, transient, transient, transient)
+	send(t, q, transport.Message{ChatID: 1, Text: "a", RunID: 1})
+
+	for range 3 {
+		q.drain(context.Background())
+		clock.advance(maxBackoff)
+	}
+	if len(sender.sent) != 0 || repo.row(1).state != "failed" || repo.row(1).Attempts != 3 || repo.runs[1] != "failed" {
+		t.Errorf("sent %v, state %q after %d attempts, run %q", sender.sent, repo.row(1).state, repo.row(1).Attempts, repo.runs[1])
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{1: 2 * time.Second, 2: 4 * time.Second, 5: 32 * time.Second, 20: maxBackoff} {
+		if got := backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func texts(s []sent) []string {
+	out := make([]string, 0, len(s))
+	for _, m := range s {
+		out = append(out, m.text)
+	}
+	return out
+}
@@ -0,0 +1,253 @@
+// Package render builds user-facing notification text from text/template templates.
+//
+// Built-in templates can be overridden per subscription. Helper functions are aware of the
+// Telegram parse mode, so the same template renders correctly as HTML, MarkdownV2 or plain text.
+// Only esc and bold escape their output; t, time, join and truncate return raw text that should be
+// wrapped with esc. Literal template text is not escaped: for MarkdownV2 it must avoid reserved characters.
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/i18n"
+	"cron-weather/internal/transport"
+)
+
+// Template names.
+const (
+	Alert        = "alert"
+	AlertUpdated = "alert_updated"
+	AlertEnded   = "alert_ended"
+	Urgent       = "urgent"
+	AllClear     = "all_clear"
+	Digest       = "digest"
+	Air          = "air"
+	AirClear     = "air_clear"
+	Nowcast      = "nowcast"
+)
+
+var defaults = map[string]string{
+	Alert: `{{with .Location}}📍 {{esc .}}
+{{end}}{{with emoji .Tags}}{{.}} {{end}}{{bold .Event}}
+{{esc .Sender}}
+{{esc (t "alert.severity" (t (printf "severity.%s" .Severity)))}}
+{{esc (truncate 1500 .Description)}}
+{{esc (t "alert.period" (time .Start) (time .End))}}{{if .Tags}}
+{{esc (t "alert.tags" (join .Tags ", "))}}{{end}}`,
+
+	AlertUpdated: `{{with .Location}}📍 {{esc .}}
+{{end}}{{bold (t "alert.updated")}}: {{with emoji .Tags}}{{.}} {{end}}{{bold .Event}}
+{{esc .Sender}}
+{{esc (t "alert.severity" (t (printf "severity.%s" .Severity)))}}
+{{esc (truncate 1500 .Description)}}
+{{esc (t "alert.period" (time .Start) (time .End))}}
+{{esc (t "alert.changes" (join .Changes "; "))}}`,
+
+	AlertEnded: `{{with .Location}}📍 {{esc .}}
+{{end}}{{bold (t "alert.ended")}}: {{esc .Event}}, {{esc .Sender}}, {{esc (t "alert.since" (time .Start))}}`,
+
+	Urgent: `{{with .Location}}📍 {{esc .}}
+{{end}}⚠️ {{bold (t "urgent")}}{{if .Description}}
+{{esc .Description}}, {{esc (temp .Temp)}}, {{esc (t "wind" (speed .WindSpeed))}}{{end}}`,
+
+	AllClear: `{{with .Location}}📍 {{esc .}}
+{{end}}✅ {{esc (t "all_clear")}}`,
+
+	Air: `{{with .Location}}📍 {{esc .}}
+{{end}}😷 {{bold (t "air.title" .AQI)}}: {{esc (t (printf "air.level.%d" .AQI))}}
+{{esc (t "air.pm" (printf "%.1f" .PM25) (printf "%.1f" .PM10))}}`,
+
+	AirClear: `{{with .Location}}📍 {{esc .}}
+{{end}}🌿 {{esc (t "air.clear" .AQI)}}`,
+
+	Nowcast: `{{with .Location}}📍 {{esc .}}
+{{end}}🌧 {{if .StartsIn}}{{esc (t "nowcast.starts" .StartsIn)}}{{else}}{{esc (t "nowcast.now")}}{{end}}, {{if .Open}}{{esc (t "nowcast.lasting_open" .Duration)}}{{else}}{{esc (t "nowcast.lasting" .Duration)}}{{end}}`,
+
+	Digest: `{{bold (t "digest.title")}}: {{len .Items}}
+
+{{range $i, $it := .Items}}{{if $i}}
+
+{{end}}{{$it}}{{end}}`,
+}
+
+// Options control template selection and helper behaviour.
+type Options struct {
+	// ParseMode is the Telegram parse mode the output is rendered for.
+	ParseMode string
+	// Location is used by the time helper (defaults to UTC).
+	Location *time.Location
+	// Lang selects the message catalog used by the t helper and the time layout.
+	Lang string
+	// Units selects unit symbols for the temp and speed helpers (metric by default).
+	Units string
+	// Overrides replace built-in templates by name.
+	Overrides map[string]string
+}
+
+// Names returns all known template names in stable order.
+func Names() []string {
+	out := make([]string, 0, len(defaults))
+	for name := range defaults {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Default returns the built-in template body for name.
+func Default(name string) (string, bool) {
+	body, ok := defaults[name]
+	return body, ok
+}
+
+// Validate checks that body is a valid template for the given name.
+func Validate(name, body string) error {
+	if _, ok := defaults[name]; !ok {
+		return fmt.Errorf("unknown template %q", name)
+	}
+	_, err := template.New(name).Funcs(funcs(Options{})).Parse(body)
+	return err
+}
+
+// Render executes the named template with data.
+// If an override fails, the error is returned together with the built-in rendering.
+func Render(name string, data any, opts Options) (string, error) {
+	body, ok := defaults[name]
+	if !ok {
+		return "", fmt.Errorf("unknown template %q", name)
+	}
+
+	if override, ok := opts.Overrides[name]; ok && strings.TrimSpace(override) != "" {
+		out, err := execute(name, override, data, opts)
+		if err == nil {
+			return out, nil
+		}
+		fallback, ferr := execute(name, body, data, opts)
+		if ferr != nil {
+			return "", ferr
+		}
+		return fallback, fmt.Errorf("template override %q: %w", name, err)
+	}
+
+	return execute(name, body, data, opts)
+}
+
+func execute(name, body string, data any, opts Options) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs(opts)).Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("parse template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+func funcs(opts Options) template.FuncMap {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	esc := func(s string) string { return Escape(opts.ParseMode, s) }
+
+	return template.FuncMap{
+		"esc": esc,
+		"bold": func(s string) string {
+			switch opts.ParseMode {
+			case transport.ParseModeHTML:
+				return "<b>" + esc(s) + "</b>"
+			case transport.ParseModeMarkdownV2:
+				return "*" + esc(s) + "*"
+			default:
+				return s
+			}
+		},
+		"t": func(key string, args ...any) string {
+			return i18n.T(opts.Lang, key, args...)
+		},
+		"time": func(t time.Time) string {
+			if t.IsZero() {
+				return "-"
+			}
+			return t.In(loc).Format(i18n.TimeLayout(opts.Lang))
+		},
+		"temp": func(v float64) string {
+			return fmt.Sprintf("%.0f%s", v, i18n.T(opts.Lang, "unit.temp."+unitsOrMetric(opts.Units)))
+		},
+		"speed": func(v float64) string {
+			return fmt.Sprintf("%.1f %s", v, i18n.T(opts.Lang, "unit.speed."+unitsOrMetric(opts.Units)))
+		},
+		"emoji":    Emoji,
+		"truncate": Truncate,
+		"join":     strings.Join,
+	}
+}
+
+func unitsOrMetric(units string) string {
+	if units == "" {
+		return domain.UnitsMetric
+	}
+	return units
+}
+
+// Escape escapes s for the given Telegram parse mode.
+func Escape(parseMode, s string) string {
+	return transport.Escape(parseMode, s)
+}
+
+// Truncate shortens s to at most n runes, appending an ellipsis when cut.
+func Truncate(n int, s string) string {
+	s = strings.TrimSpace(s)
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
+
+var tagEmoji = []struct {
+	match string
+	emoji string
+}{
+	{"thunder", "⛈"},
+	{"hurricane", "🌀"},
+	{"tornado", "🌪"},
+	{"wind", "💨"},
+	{"rain", "🌧"},
+	{"flood", "🌊"},
+	{"coastal", "🌊"},
+	{"tide", "🌊"},
+	{"snow", "❄️"},
+	{"ice", "🧊"},
+	{"avalanche", "🏔"},
+	{"fog", "🌫"},
+	{"fire", "🔥"},
+	{"extreme temperature", "🌡"},
+	{"heat", "🌡"},
+	{"cold", "🥶"},
+	{"air quality", "😷"},
+}
+
+// Emoji returns emoji for known OpenWeather alert tags (deduplicated, in tag order).
+func Emoji(tags []string) string {
+	var out []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		t := strings.ToLower(tag)
+		for _, te := range tagEmoji {
+			if strings.Contains(t, te.match) && !seen[te.emoji] {
+				seen[te.emoji] = true
+				out = append(out, te.emoji)
+				break
+			}
+		}
+	}
+	return strings.Join(out, "")
+}
@@ -3,6 +3,7 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -39,14 +40,45 @@ type Engine struct {
 	mu     sync.RWMutex
 	entry  map[string]cron.EntryID // scheduleID -> cron entry id
 	closed bool
+
+	// redact hides secrets in error texts before they are persisted.
+	redact func(string) string
+
+	// loc is the default time zone for quiet hours.
+	loc *time.Location
+	// quietBypass is the lowest message severity delivered during quiet hours.
+	quietBypass domain.Severity
+	// merge joins short messages of one run into fewer Telegram messages.
+	merge bool
+
+	// now is the clock of runs and held message flushes; tests replace it.
+	now func() time.Time
+}
+
+const (
+	// heldFlushSpec is how often held messages are checked for delivery.
+	heldFlushSpec = "@every 1m"
+	// heldLease keeps claimed held messages from other replicas while they are delivered.
+	heldLease = 5 * time.Minute
+	// heldMaxAttempts is how many failed deliveries drop a held message.
+	heldMaxAttempts = 5
+	// heldMaxBackoff caps the wait between delivery attempts of held messages.
+	heldMaxBackoff = 30 * time.Minute
+)
+
+// parser accepts 5 or 6 field cron expressions (seconds optional) and descriptors like @daily.
+var parser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// ValidateSpec reports whether expr is a cron expression the engine can schedule.
+func ValidateSpec(expr string) error {
+	_, err := parser.Parse(expr)
+	return err
 }
 
 // New creates a scheduler Engine with the given repository, producer and task runners.
 func New(log *slog.Logger, repo storage.Repo, producer transport.Producer, runners map[string]task.Runner, tz string) *Engine {
-	parser := cron.NewParser(
-		cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
-	)
-
 	tz = strings.TrimSpace(tz)
 	if tz == "" {
 		tz = "UTC"
@@ -78,9 +110,35 @@ func New(log *slog.Logger, repo storage.Repo, producer transport.Producer, runne
 		cron:     c,
 		runners:  runners,
 		entry:    make(map[string]cron.EntryID),
+
+		loc:         loc,
+		quietBypass: domain.SeveritySevere,
+		merge:       true,
+		now:         time.Now,
 	}
 }
 
+// SetRedact sets a function that hides secrets in run error texts before they are stored.
+func (e *Engine) SetRedact(fn func(string) string) {
+	e.redact = fn
+}
+
+// SetQuietBypass sets the lowest message severity that is delivered during quiet hours.
+func (e *Engine) SetQuietBypass(s domain.Severity) {
+	e.quietBypass = s
+}
+
+// SetMergeMessages sets whether short messages of one run are sent as one message.
+func (e *Engine) SetMergeMessages(merge bool) {
+	e.merge = merge
+}
+
+// HasKind reports whether a runner is registered for the schedule kind.
+func (e *Engine) HasKind(kind string) bool {
+	_, ok := e.runners[kind]
+	return ok
+}
+
 // Start bootstraps active schedules from storage and starts the cron loop.
 func (e *Engine) Start(ctx context.Context) error {
 	if e.repo == nil {
@@ -99,6 +157,12 @@ func (e *Engine) Start(ctx context.Context) error {
 		}
 	}
 
+	if _, err := e.cron.AddFunc(heldFlushSpec, func() {
+		e.flushHeld(context.Background())
+	}); err != nil {
+		return fmt.Errorf("register held messages flush: %w", err)
+	}
+
 	e.cron.Start()
 	return nil
 }
@@ -122,7 +186,7 @@ func (e *Engine) Stop(ctx context.Context) {
 	}
 }
 
-// AddByID loads the scheduler from DB and registers it.
+// AddByID loads the scheduler from DB and registers it. Paused schedules are not registered.
 func (e *Engine) AddByID(ctx context.Context, schedulerID string) error {
 	if e.repo == nil {
 		return nil
@@ -131,9 +195,25 @@ func (e *Engine) AddByID(ctx context.Context, schedulerID string) error {
 	if err != nil {
 		return err
 	}
+	if it.Scheduler.Paused {
+		return nil
+	}
 	return e.Add(ctx, it)
 }
 
+// RunNow runs an active schedule once in the background, whether it is paused or not.
+func (e *Engine) RunNow(ctx context.Context, schedulerID string) error {
+	if e.repo == nil {
+		return fmt.Errorf("no repo configured")
+	}
+	it, err := e.repo.GetActiveScheduler(ctx, schedulerID)
+	if err != nil {
+		return err
+	}
+	go e.run(context.Background(), it)
+	return nil
+}
+
 // Add registers a scheduler in cron. Safe to call multiple times; it will replace existing entry.
 func (e *Engine) Add(ctx context.Context, it domain.SchedulerWithTarget) error {
 	e.mu.Lock()
@@ -189,7 +269,7 @@ func (e *Engine) Remove(ctx context.Context, schedulerID string) {
 }
 
 func (e *Engine) run(ctx context.Context, it domain.SchedulerWithTarget) {
-	now := time.Now()
+	now := e.now()
 	start := time.Now()
 
 	// Log each run start (prod-relevant event).
@@ -201,6 +281,14 @@ func (e *Engine) run(ctx context.Context, it domain.SchedulerWithTarget) {
 		slog.Time("scheduled_for", now),
 	)
 
+	// Reload the schedule so subscription settings changed after registration
+	// (location, language, templates) apply to this run.
+	if e.repo != nil {
+		if fresh, err := e.repo.GetActiveScheduler(ctx, it.Scheduler.ID); err == nil {
+			it = fresh
+		}
+	}
+
 	// Respect starts_at / ends_at.
 	if it.Scheduler.StartAt != nil && now.Before(*it.Scheduler.StartAt) {
 		return
@@ -217,6 +305,11 @@ func (e *Engine) run(ctx context.Context, it domain.SchedulerWithTarget) {
 	status := "success"
 	errText := ""
 	payload := ""
+	// outgoing messages are handed to the producer once the run is recorded,
+	// so queued deliveries can report back to it.
+	var outgoing []transport.Message
+	// commit is called when all messages of the run were handed off.
+	var commit func(ctx context.Context) error
 
 	// Pick runner by schedule kind (fallback to "cron" for backward-compat).
 	kind := it.Scheduler.Kind
@@ -232,6 +325,7 @@ func (e *Engine) run(ctx context.Context, it domain.SchedulerWithTarget) {
 			Scheduler:    it.Scheduler,
 			Subscription: it.Subscription,
 			Target:       it.Target,
+			Location:     it.Location,
 			ScheduledFor: now,
 		})
 		if err != nil {
@@ -239,21 +333,46 @@ func (e *Engine) run(ctx context.Context, it domain.SchedulerWithTarget) {
 			errText = err.Error()
 		} else {
 			payload = res.Payload
+			commit = res.Commit
 			// Deliver messages to endpoint.
 			if it.Target.Kind == "telegram" {
 				chatID, perr := strconv.ParseInt(it.Target.Address, 10, 64)
 				if perr != nil {
 					status = "error"
 					errText = fmt.Sprintf("invalid telegram chat_id address: %v", perr)
+					commit = nil
 				} else if e.producer != nil {
+					quiet := e.repo != nil && it.Subscription.InQuietHours(now, e.loc)
+					var held []domain.HeldMessage
 					for _, m := range res.Messages {
-						if strings.TrimSpace(m) == "" {
+						if strings.TrimSpace(m.Text) == "" {
+							continue
+						}
+						// Non-urgent messages wait for the end of quiet hours.
+						if quiet && m.Severity < e.quietBypass {
+							held = append(held, domain.HeldMessage{
+								SubscriptionID: it.Scheduler.SubscriptionID,
+								Target:         it.Target,
+								Text:           m.Text,
+								ParseMode:      m.ParseMode,
+							})
 							continue
 						}
-						if serr := e.producer.Send(ctx, transport.Message{ChatID: chatID, Text: m}); serr != nil {
+						outgoing = append(outgoing, transport.Message{ChatID: chatID, Text: m.Text, ParseMode: m.ParseMode})
+					}
+					if len(held) > 0 {
+						if herr := e.repo.HoldMessages(ctx, held); herr != nil {
+							// Nothing of the run is delivered, so the next run produces it all again.
 							status = "error"
-							errText = serr.Error()
-							break
+							errText = herr.Error()
+							outgoing = nil
+							commit = nil
+						} else {
+							e.log.Info("messages held for quiet hours",
+								slog.String("scheduler_id", it.Scheduler.ID),
+								slog.String("subscription_id", it.Scheduler.SubscriptionID),
+								slog.Int("held", len(held)),
+							)
 						}
 					}
 				}
@@ -261,12 +380,46 @@ func (e *Engine) run(ctx context.Context, it domain.SchedulerWithTarget) {
 				// unsupported target kind for now
 				status = "error"
 				errText = "unsupported endpoint kind"
+				commit = nil
 			}
 		}
 	}
 
+	if e.redact != nil {
+		errText = e.redact(errText)
+	}
+	var runID int64
 	if e.repo != nil {
-		_ = e.repo.InsertRun(ctx, it.Scheduler.SubscriptionID, it.Scheduler.ID, now, status, payload, errText)
+		runID, _ = e.repo.InsertRun(ctx, it.Scheduler.SubscriptionID, it.Scheduler.ID, now, status, payload, errText)
+	}
+	if e.merge {
+		outgoing = transport.Merge(outgoing, transport.MaxMessageLength)
+	}
+	for _, m := range outgoing {
+		m.RunID = runID
+		if serr := e.producer.Send(ctx, m); serr != nil {
+			status = "error"
+			errText = "send: " + serr.Error()
+			if e.redact != nil {
+				errText = e.redact(errText)
+			}
+			if runID != 0 {
+				if ferr := e.repo.FailRunDelivery(ctx, runID, errText); ferr != nil {
+					e.log.Error("failed to record run delivery failure", slog.Any("err", ferr), slog.Int64("run_id", runID))
+				}
+			}
+			commit = nil
+			break
+		}
+	}
+	if commit != nil {
+		if err := commit(ctx); err != nil {
+			e.log.Error("failed to record run state",
+				slog.Any("err", err),
+				slog.String("scheduler_id", it.Scheduler.ID),
+				slog.Int64("run_id", runID),
+			)
+		}
 	}
 
 	e.mu.RLock()
@@ -295,3 +448,126 @@ func (e *Engine) run(ctx context.Context, it domain.SchedulerWithTarget) {
 		e.log.Warn("schedule run finished")
 	}
 }
+
+// flushHeld delivers messages held during quiet hours of subscriptions whose window has ended.
+// Messages of one chat are sent as a batch: consecutive messages with the same parse mode are merged.
+// A batch is deleted only after the hand-off. Batches that cannot be delivered (unknown endpoint,
+// invalid chat, permanent transport error) are dropped; other failures are retried with backoff
+// up to heldMaxAttempts, and later messages of the subscription wait to keep their order.
+func (e *Engine) flushHeld(ctx context.Context) {
+	if e.repo == nil || e.producer == nil {
+		return
+	}
+	subs, err := e.repo.ListHeldSubscriptions(ctx)
+	if err != nil {
+		e.log.Error("failed to list held messages", slog.Any("err", err))
+		return
+	}
+
+	now := e.now()
+	for _, sub := range subs {
+		if sub.InQuietHours(now, e.loc) {
+			continue
+		}
+		msgs, err := e.repo.ClaimHeldMessages(ctx, sub.ID, now, now.Add(heldLease))
+		if err != nil {
+			e.log.Error("failed to claim held messages", slog.Any("err", err), slog.String("subscription_id", sub.ID))
+			continue
+		}
+
+		delivered := 0
+		for i := 0; i < len(msgs); {
+			j := i + 1
+			for j < len(msgs) && msgs[j].Target == msgs[i].Target && msgs[j].ParseMode == msgs[i].ParseMode {
+				j++
+			}
+			batch := msgs[i:j]
+			err := e.sendHeld(ctx, batch)
+			if err == nil {
+				e.deleteHeld(ctx, sub.ID, batch)
+				delivered += len(batch)
+				i = j
+				continue
+			}
+
+			var se *transport.SendError
+			attempt := batch[0].Attempts + 1
+			if errors.As(err, &se) && se.Permanent || attempt >= heldMaxAttempts {
+				e.log.Error("dropping held messages",
+					slog.Any("err", err),
+					slog.String("subscription_id", sub.ID),
+					slog.Int("count", len(batch)),
+					slog.Int("attempts", attempt),
+				)
+				e.deleteHeld(ctx, sub.ID, batch)
+				i = j
+				continue
+			}
+
+			e.log.Warn("held messages will be retried",
+				slog.Any("err", err),
+				slog.String("subscription_id", sub.ID),
+				slog.Int("attempts", attempt),
+			)
+			at := now.Add(heldBackoff(attempt))
+			if rerr := e.repo.RetryHeldMessages(ctx, heldIDs(batch), at); rerr != nil {
+				e.log.Error("failed to reschedule held messages", slog.Any("err", rerr), slog.String("subscription_id", sub.ID))
+			}
+			if rest := msgs[j:]; len(rest) > 0 {
+				if rerr := e.repo.DeferHeldMessages(ctx, heldIDs(rest), at); rerr != nil {
+					e.log.Error("failed to reschedule held messages", slog.Any("err", rerr), slog.String("subscription_id", sub.ID))
+				}
+			}
+			break
+		}
+		if delivered > 0 {
+			e.log.Info("held messages delivered",
+				slog.String("subscription_id", sub.ID),
+				slog.Int("count", delivered),
+			)
+		}
+	}
+}
+
+// deleteHeld removes handed-off or dropped held messages; if that fails, the lease
+// expires and they are delivered again.
+func (e *Engine) deleteHeld(ctx context.Context, subscriptionID string, msgs []domain.HeldMessage) {
+	if err := e.repo.DeleteHeldMessages(ctx, heldIDs(msgs)); err != nil {
+		e.log.Error("failed to delete held messages", slog.Any("err", err), slog.String("subscription_id", subscriptionID))
+	}
+}
+
+func heldIDs(msgs []domain.HeldMessage) []int64 {
+	ids := make([]int64, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+// heldBackoff returns the wait before the next delivery attempt, doubling from one minute.
+func heldBackoff(attempt int) time.Duration {
+	d := time.Minute
+	for i := 1; i < attempt && d < heldMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, heldMaxBackoff)
+}
+
+// sendHeld sends messages with one target and parse mode as a single message;
+// the producer splits it when it is too long.
+func (e *Engine) sendHeld(ctx context.Context, msgs []domain.HeldMessage) error {
+	first := msgs[0]
+	if first.Target.Kind != "telegram" {
+		return &transport.SendError{Err: fmt.Errorf("unsupported endpoint kind %q", first.Target.Kind), Permanent: true}
+	}
+	chatID, err := strconv.ParseInt(first.Target.Address, 10, 64)
+	if err != nil {
+		return &transport.SendError{Err: fmt.Errorf("invalid telegram chat_id address: %w", err), Permanent: true}
+	}
+	texts := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		texts = append(texts, m.Text)
+	}
+	return e.producer.Send(ctx, transport.Message{ChatID: chatID, Text: strings.Join(texts, "\n\n"), ParseMode: first.ParseMode})
+}
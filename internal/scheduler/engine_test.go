@@ -0,0 +1,308 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/storage"
+	"cron-weather/internal/task"
+	"cron-weather/internal/transport"
+)
+
+// memRepo keeps runs and held messages in memory.
+// Methods the engine does not use in these tests panic through the nil embedded Repo.
+type memRepo struct {
+	storage.Repo
+
+	subs    []domain.Subscription
+	held    []*heldRow
+	runs    []string
+	holdErr error
+}
+
+type heldRow struct {
+	domain.HeldMessage
+	nextAt time.Time
+}
+
+func (r *memRepo) GetActiveScheduler(context.Context, string) (domain.SchedulerWithTarget, error) {
+	return domain.SchedulerWithTarget{}, storage.ErrNotFound
+}
+
+func (r *memRepo) InsertRun(_ context.Context, _, _ string, _ time.Time, status, _, _ string) (int64, error) {
+	r.runs = append(r.runs, status)
+	return int64(len(r.runs)), nil
+}
+
+func (r *memRepo) FailRunDelivery(_ context.Context, runID int64, _ string) error {
+	r.runs[runID-1] = "error"
+	return nil
+}
+
+func (r *memRepo) HoldMessages(_ context.Context, msgs []domain.HeldMessage) error {
+	if r.holdErr != nil {
+		return r.holdErr
+	}
+	for _, m := range msgs {
+		m.ID = int64(len(r.held) + 1)
+		r.held = append(r.held, &heldRow{HeldMessage: m})
+	}
+	return nil
+}
+
+func (r *memRepo) ListHeldSubscriptions(context.Context) ([]domain.Subscription, error) {
+	return r.subs, nil
+}
+
+func (r *memRepo) ClaimHeldMessages(_ context.Context, subscriptionID string, now, leaseUntil time.Time) ([]domain.HeldMessage, error) {
+	var out []domain.HeldMessage
+	for _, h := range r.held {
+		if h.SubscriptionID == subscriptionID && !h.nextAt.After(now) {
+			h.nextAt = leaseUntil
+			out = append(out, h.HeldMessage)
+		}
+	}
+	return out, nil
+}
+
+func (r *memRepo) update(ids []int64, fn func(h *heldRow)) {
+	for _, h := range r.held {
+		if slices.Contains(ids, h.ID) {
+			fn(h)
+		}
+	}
+}
+
+func (r *memRepo) DeferHeldMessages(_ context.Context, ids []int64, at time.Time) error {
+	r.update(ids, func(h *heldRow) { h.nextAt = at })
+	return nil
+}
+
+func (r *memRepo) RetryHeldMessages(_ context.Context, ids []int64, at time.Time) error {
+	r.update(ids, func(h *heldRow) { h.Attempts++; h.nextAt = at })
+	return nil
+}
+
+func (r *memRepo) DeleteHeldMessages(_ context.Context, ids []int64) error {
+	r.held = slices.DeleteFunc(r.held, func(h *heldRow) bool { return slices.Contains(ids, h.ID) })
+	return nil
+}
+
+// fakeProducer records sent texts; errs are returned by the next calls, one per call.
+type fakeProducer struct {
+	errs []error
+	sent []string
+}
+
+func (p *fakeProducer) Send(_ context.Context, msg transport.Message) error {
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	p.sent = append(p.sent, msg.Text)
+	return nil
+}
+
+// fixedRunner returns the same messages on every run and counts commits.
+type fixedRunner struct {
+	msgs    []task.Message
+	commits int
+}
+
+func (r *fixedRunner) Run(context.Context, task.Input) (task.Result, error) {
+	return task.Result{Messages: r.msgs, Commit: func(context.Context) error {
+		r.commits++
+		return nil
+	}}, nil
+}
+
+// night is a quiet window that wraps midnight.
+var night = &domain.QuietHours{Start: 22 * 60, End: 7 * 60}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func newTestEngine(repo *memRepo, producer *fakeProducer, runner task.Runner) *Engine {
+	e := New(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, producer, map[string]task.Runner{"cron": runner}, "UTC")
+	e.SetMergeMessages(false)
+	return e
+}
+
+func schedule() domain.SchedulerWithTarget {
+	return domain.SchedulerWithTarget{
+		Scheduler:    domain.Scheduler{ID: "sched", SubscriptionID: "sub"},
+		Subscription: domain.Subscription{ID: "sub", Quiet: night},
+		Target:       domain.SchedulerTarget{Kind: "telegram", Address: "1"},
+	}
+}
+
+func TestQuietHoursHoldAndBypass(t *testing.T) {
+	runner := &fixedRunner{msgs: []task.Message{
+		{Text: "alert", Severity: domain.SeverityModerate},
+		{Text: "storm", Severity: domain.SeveritySevere},
+	}}
+	tests := []struct {
+		name      string
+		now       time.Time
+		wantSent  []string
+		wantHeldN int
+	}{
+		{"before the window", at(21, 59), []string{"alert", "storm"}, 0},
+		{"evening", at(23, 30), []string{"storm"}, 1},
+		{"after midnight", at(3, 0), []string{"storm"}, 1},
+		{"last minute", at(6, 59), []string{"storm"}, 1},
+		{"window over", at(7, 0), []string{"alert", "storm"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, producer := &memRepo{}, &fakeProducer{}
+			e := newTestEngine(repo, producer, runner)
+			e.now = func() time.Time { return tt.now }
+
+			e.run(context.Background(), schedule())
+			if !slices.Equal(producer.sent, tt.wantSent) || len(repo.held) != tt.wantHeldN {
+				t.Errorf("sent %q, held %d; want %q, held %d", producer.sent, len(repo.held), tt.wantSent, tt.wantHeldN)
+			}
+		})
+	}
+}
+
+func TestQuietHoursBypassSeverity(t *testing.T) {
+	runner := &fixedRunner{msgs: []task.Message{{Text: "alert", Severity: domain.SeverityModerate}}}
+	repo, producer := &memRepo{}, &fakeProducer{}
+	e := newTestEngine(repo, producer, runner)
+	e.SetQuietBypass(domain.SeverityModerate)
+	e.now = func() time.Time { return at(23, 0) }
+
+	e.run(context.Background(), schedule())
+	if !slices.Equal(producer.sent, []string{"alert"}) || len(repo.held) != 0 {
+		t.Errorf("sent %q, held %d; want the moderate alert delivered", producer.sent, len(repo.held))
+	}
+}
+
+func TestHoldFailureSendsNothing(t *testing.T) {
+	runner := &fixedRunner{msgs: []task.Message{
+		{Text: "alert", Severity: domain.SeverityModerate},
+		{Text: "storm", Severity: domain.SeveritySevere},
+	}}
+	repo, producer := &memRepo{holdErr: errors.New("db down")}, &fakeProducer{}
+	e := newTestEngine(repo, producer, runner)
+	e.now = func() time.Time { return at(23, 0) }
+
+	e.run(context.Background(), schedule())
+	if len(producer.sent) != 0 || runner.commits != 0 || !slices.Equal(repo.runs, []string{"error"}) {
+		t.Errorf("sent %q, commits %d, runs %q; want nothing delivered and an error run", producer.sent, runner.commits, repo.runs)
+	}
+}
+
+func TestSendFailureFailsRun(t *testing.T) {
+	runner := &fixedRunner{msgs: []task.Message{{Text: "storm", Severity: domain.SeveritySevere}}}
+	repo, producer := &memRepo{}, &fakeProducer{errs: []error{errors.New("queue full")}}
+	e := newTestEngine(repo, producer, runner)
+	e.now = func() time.Time { return at(12, 0) }
+
+	e.run(context.Background(), schedule())
+	if runner.commits != 0 || !slices.Equal(repo.runs, []string{"error"}) {
+		t.Errorf("commits %d, runs %q; want an error run without commit", runner.commits, repo.runs)
+	}
+}
+
+// holdTwo holds two messages of the night subscription.
+func holdTwo(t *testing.T, repo *memRepo, target domain.SchedulerTarget) {
+	t.Helper()
+	repo.subs = []domain.Subscription{{ID: "sub", Quiet: night}}
+	err := repo.HoldMessages(context.Background(), []domain.HeldMessage{
+		{SubscriptionID: "sub", Target: target, Text: "a"},
+		{SubscriptionID: "sub", Target: target, Text: "b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFlushHeldAfterWindow(t *testing.T) {
+	repo, producer := &memRepo{}, &fakeProducer{}
+	holdTwo(t, repo, domain.SchedulerTarget{Kind: "telegram", Address: "1"})
+	e := newTestEngine(repo, producer, nil)
+
+	e.now = func() time.Time { return at(6, 30) }
+	e.flushHeld(context.Background())
+	if len(producer.sent) != 0 {
+		t.Fatalf("flushed %q during quiet hours", producer.sent)
+	}
+
+	e.now = func() time.Time { return at(7, 1) }
+	e.flushHeld(context.Background())
+	if !slices.Equal(producer.sent, []string{"a\n\nb"}) || len(repo.held) != 0 {
+		t.Errorf("sent %q, held %d; want one batch and nothing left", producer.sent, len(repo.held))
+	}
+}
+
+func TestFlushHeldRetriesWithBackoffAndDrops(t *testing.T) {
+	transient := errors.New("queue unavailable")
+	producer := &fakeProducer{errs: slices.Repeat([]error{transient}, heldMaxAttempts)}
+	repo := &memRepo{}
+	holdTwo(t, repo, domain.SchedulerTarget{Kind: "telegram", Address: "1"})
+	e := newTestEngine(repo, producer, nil)
+
+	now := at(8, 0)
+	e.now = func() time.Time { return now }
+	for attempt := 1; attempt < heldMaxAttempts; attempt++ {
+		e.flushHeld(context.Background())
+		if len(repo.held) != 2 || repo.held[0].Attempts != attempt {
+			t.Fatalf("attempt %d: held %d with %d attempts", attempt, len(repo.held), repo.held[0].Attempts)
+		}
+		wait := repo.held[0].nextAt.Sub(now)
+		if wait != heldBackoff(attempt) {
+			t.Fatalf("attempt %d: next try in %v, want %v", attempt, wait, heldBackoff(attempt))
+		}
+
+		// Nothing is claimed before the backoff is over.
+		now = now.Add(wait - time.Second)
+		e.flushHeld(context.Background())
+		now = now.Add(time.Second)
+	}
+
+	e.flushHeld(context.Background())
+	if len(repo.held) != 0 || len(producer.sent) != 0 {
+		t.Errorf("after %d attempts: held %d, sent %q; want the batch dropped", heldMaxAttempts, len(repo.held), producer.sent)
+	}
+}
+
+func TestFlushHeldDropsUndeliverable(t *testing.T) {
+	repo, producer := &memRepo{}, &fakeProducer{}
+	holdTwo(t, repo, domain.SchedulerTarget{Kind: "telegram", Address: "not-a-chat"})
+	e := newTestEngine(repo, producer, nil)
+	e.now = func() time.Time { return at(8, 0) }
+
+	e.flushHeld(context.Background())
+	if len(repo.held) != 0 || len(producer.sent) != 0 {
+		t.Errorf("held %d, sent %q; want the invalid chat dropped", len(repo.held), producer.sent)
+	}
+
+	// A permanent transport error drops the batch as well.
+	producer.errs = []error{&transport.SendError{Err: errors.New("chat not found"), Permanent: true}}
+	holdTwo(t, repo, domain.SchedulerTarget{Kind: "telegram", Address: "1"})
+	e.flushHeld(context.Background())
+	if len(repo.held) != 0 {
+		t.Errorf("held %d after a permanent error, want 0", len(repo.held))
+	}
+}
+
+func TestHeldBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 5: 16 * time.Minute, 10: heldMaxBackoff} {
+		if got := heldBackoff(attempt); got != want {
+			t.Errorf("heldBackoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+-- +goose Up
+
+-- Last delivered content per alert identity (sender, event, start).
+-- Used to detect alert updates and alerts that disappeared from the API response.
+CREATE TABLE IF NOT EXISTS alert_state (
+    subscription_id uuid NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    alert_key TEXT NOT NULL,
+    snapshot TEXT NOT NULL,
+    active boolean NOT NULL DEFAULT true,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, alert_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_state_active ON alert_state (subscription_id) WHERE active;
+
+-- +goose Down
+
+DROP TABLE IF EXISTS alert_state;
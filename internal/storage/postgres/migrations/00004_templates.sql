@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- Parse mode for task messages rendered from templates
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS parse_mode TEXT NOT NULL DEFAULT 'HTML';
+
+-- Per-subscription overrides of built-in message templates
+CREATE TABLE IF NOT EXISTS message_templates (
+    subscription_id uuid NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    body TEXT NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, name)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS message_templates;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS parse_mode;
@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- Per-subscription language for bot replies and weather output (NULL means service default)
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS lang TEXT;
+
+-- +goose Down
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS lang;
@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- Per-subscription measurement units: metric, imperial or standard (NULL means metric)
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS units TEXT;
+
+-- +goose Down
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS units;
@@ -0,0 +1,33 @@
+-- +goose Up
+
+-- NULL coordinates mean "location unset"; 0,0 is a valid point.
+ALTER TABLE subscriptions
+    ALTER COLUMN lat DROP NOT NULL,
+    ALTER COLUMN lat DROP DEFAULT,
+    ALTER COLUMN lon DROP NOT NULL,
+    ALTER COLUMN lon DROP DEFAULT;
+
+-- Zero pairs were the old "unset" marker; out-of-range values were never valid.
+UPDATE subscriptions
+SET lat = NULL, lon = NULL
+WHERE (lat = 0 AND lon = 0)
+   OR lat NOT BETWEEN -90 AND 90
+   OR lon NOT BETWEEN -180 AND 180;
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_location_pair CHECK ((lat IS NULL) = (lon IS NULL)),
+    ADD CONSTRAINT subscriptions_location_range CHECK (lat BETWEEN -90 AND 90 AND lon BETWEEN -180 AND 180);
+
+-- +goose Down
+
+ALTER TABLE subscriptions
+    DROP CONSTRAINT IF EXISTS subscriptions_location_range,
+    DROP CONSTRAINT IF EXISTS subscriptions_location_pair;
+
+UPDATE subscriptions SET lat = 0, lon = 0 WHERE lat IS NULL OR lon IS NULL;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN lat SET DEFAULT 0,
+    ALTER COLUMN lat SET NOT NULL,
+    ALTER COLUMN lon SET DEFAULT 0,
+    ALTER COLUMN lon SET NOT NULL;
@@ -0,0 +1,56 @@
+-- +goose Up
+
+-- Named places per subscription ("home", "dacha", ...)
+CREATE TABLE IF NOT EXISTS locations (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid (),
+    subscription_id uuid NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    lat DOUBLE PRECISION NOT NULL CHECK (lat BETWEEN -90 AND 90),
+    lon DOUBLE PRECISION NOT NULL CHECK (lon BETWEEN -180 AND 180),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (subscription_id, name)
+);
+
+-- Schedule target place (NULL means subscription coordinates)
+ALTER TABLE schedules
+    ADD COLUMN IF NOT EXISTS location_id uuid REFERENCES locations(id) ON DELETE SET NULL;
+
+-- Scope dedup per location ('' means subscription coordinates)
+ALTER TABLE sent_alerts
+    ADD COLUMN IF NOT EXISTS location_key TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE sent_alerts
+    DROP CONSTRAINT IF EXISTS sent_alerts_pkey,
+    ADD PRIMARY KEY (subscription_id, location_key, fingerprint);
+
+ALTER TABLE alert_state
+    ADD COLUMN IF NOT EXISTS location_key TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE alert_state
+    DROP CONSTRAINT IF EXISTS alert_state_pkey,
+    ADD PRIMARY KEY (subscription_id, location_key, alert_key);
+
+-- +goose Down
+
+DELETE FROM alert_state WHERE location_key <> '';
+
+ALTER TABLE alert_state
+    DROP CONSTRAINT IF EXISTS alert_state_pkey,
+    ADD PRIMARY KEY (subscription_id, alert_key);
+
+ALTER TABLE alert_state
+    DROP COLUMN IF EXISTS location_key;
+
+DELETE FROM sent_alerts WHERE location_key <> '';
+
+ALTER TABLE sent_alerts
+    DROP CONSTRAINT IF EXISTS sent_alerts_pkey,
+    ADD PRIMARY KEY (subscription_id, fingerprint);
+
+ALTER TABLE sent_alerts
+    DROP COLUMN IF EXISTS location_key;
+
+ALTER TABLE schedules
+    DROP COLUMN IF EXISTS location_id;
+
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- Shared One Call responses (key: rounded coordinates, lang, units)
+CREATE TABLE IF NOT EXISTS weather_cache (
+    key TEXT PRIMARY KEY,
+    body BYTEA NOT NULL,
+    fetched_at timestamptz NOT NULL
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS weather_cache;
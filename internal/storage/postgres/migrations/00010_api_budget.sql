@@ -0,0 +1,13 @@
+-- +goose Up
+
+-- Account-wide API usage per period ('2006-01-02' for days, '2006-01' for months)
+CREATE TABLE IF NOT EXISTS api_budget (
+    period TEXT PRIMARY KEY,
+    used INT NOT NULL DEFAULT 0,
+    -- highest usage threshold (percent) the admin was notified about
+    notified_pct INT NOT NULL DEFAULT 0
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS api_budget;
@@ -0,0 +1,32 @@
+-- +goose Up
+
+-- Per-subscription time zone and do-not-disturb window (minutes since midnight, NULL means disabled)
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS tz TEXT,
+    ADD COLUMN IF NOT EXISTS quiet_start INT CHECK (quiet_start BETWEEN 0 AND 1439),
+    ADD COLUMN IF NOT EXISTS quiet_end INT CHECK (quiet_end BETWEEN 0 AND 1439);
+
+-- Task messages postponed until quiet hours end. They are claimed with a lease
+-- (next_attempt_at) and deleted after the hand-off; failed flushes are retried with backoff.
+CREATE TABLE IF NOT EXISTS held_messages (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id uuid NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    target_kind TEXT NOT NULL,
+    target_address TEXT NOT NULL,
+    text TEXT NOT NULL,
+    parse_mode TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL DEFAULT now(),
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS held_messages_subscription_idx ON held_messages (subscription_id, id);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS held_messages;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS quiet_end,
+    DROP COLUMN IF EXISTS quiet_start,
+    DROP COLUMN IF EXISTS tz;
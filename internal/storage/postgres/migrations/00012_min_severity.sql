@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- Minimum weather alert severity per subscription (0 info .. 4 extreme; 0 delivers all alerts)
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS min_severity INT NOT NULL DEFAULT 0 CHECK (min_severity BETWEEN 0 AND 4);
+
+-- +goose Down
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS min_severity;
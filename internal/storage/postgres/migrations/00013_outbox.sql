@@ -0,0 +1,34 @@
+-- +goose Up
+
+-- Delivery state of run messages: pending, delivered or failed (NULL when the run produced none)
+ALTER TABLE runs
+    ADD COLUMN IF NOT EXISTS delivery TEXT,
+    ADD COLUMN IF NOT EXISTS delivered_at timestamptz,
+    ADD COLUMN IF NOT EXISTS delivery_error TEXT;
+
+-- Outgoing chat messages; delivered rows are deleted, failed rows are kept for inspection
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT REFERENCES runs(id) ON DELETE SET NULL,
+    chat_id BIGINT NOT NULL,
+    text TEXT NOT NULL,
+    parse_mode TEXT NOT NULL DEFAULT '',
+    state TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL DEFAULT now(),
+    last_error TEXT,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (chat_id, id) WHERE state = 'pending';
+
+CREATE INDEX IF NOT EXISTS outbox_run_idx ON outbox (run_id);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS outbox;
+
+ALTER TABLE runs
+    DROP COLUMN IF EXISTS delivery_error,
+    DROP COLUMN IF EXISTS delivered_at,
+    DROP COLUMN IF EXISTS delivery;
@@ -0,0 +1,30 @@
+-- +goose Up
+
+-- Paused schedules stay active but are not registered in the runtime cron
+ALTER TABLE schedules
+    ADD COLUMN IF NOT EXISTS paused BOOLEAN NOT NULL DEFAULT false;
+
+-- Runs are linked to their schedule for per-schedule history
+ALTER TABLE runs
+    ADD COLUMN IF NOT EXISTS schedule_id uuid REFERENCES schedules(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_runs_schedule_id ON runs (schedule_id, id);
+
+-- Inline keyboard (JSON) and the message to edit in place for interactive replies
+ALTER TABLE outbox
+    ADD COLUMN IF NOT EXISTS reply_markup TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS edit_message_id BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+
+ALTER TABLE outbox
+    DROP COLUMN IF EXISTS edit_message_id,
+    DROP COLUMN IF EXISTS reply_markup;
+
+DROP INDEX IF EXISTS idx_runs_schedule_id;
+
+ALTER TABLE runs
+    DROP COLUMN IF EXISTS schedule_id;
+
+ALTER TABLE schedules
+    DROP COLUMN IF EXISTS paused;
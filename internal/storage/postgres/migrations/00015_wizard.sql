@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- State of the interactive /new schedule wizard, one row per chat
+CREATE TABLE IF NOT EXISTS wizard_state (
+    chat_id BIGINT PRIMARY KEY,
+    step TEXT NOT NULL,
+    kind TEXT NOT NULL DEFAULT '',
+    location_id TEXT NOT NULL DEFAULT '',
+    expr TEXT NOT NULL DEFAULT '',
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS wizard_state;
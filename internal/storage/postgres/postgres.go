@@ -156,7 +156,8 @@ func (r *PostgresRepo) DeactivateSubscription(ctx context.Context, chatID int64)
 }
 
 // CreateScheduler creates a new schedule for the given chat.
-func (r *PostgresRepo) CreateScheduler(ctx context.Context, chatID int64, cronExpr string, tz string, startAt, endAt *time.Time) (string, error) {
+// Kind defaults to "cron"; LocationID, if set, must belong to the chat subscription.
+func (r *PostgresRepo) CreateScheduler(ctx context.Context, chatID int64, s domain.Scheduler) (string, error) {
 	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
 
 	// require subscription to exist (and be active)
@@ -169,13 +170,24 @@ func (r *PostgresRepo) CreateScheduler(ctx context.Context, chatID int64, cronEx
 		return "", fmt.Errorf("get subscription: %w", err)
 	}
 
+	kind := s.Kind
+	if kind == "" {
+		kind = "cron"
+	}
+
 	var scheduleID string
 	err = r.pool.QueryRow(ctx, `
-		INSERT INTO schedules(subscription_id, kind, expr, tz, starts_at, ends_at, active)
-		VALUES($1, 'cron', $2, $3, $4, $5, true)
+		INSERT INTO schedules(subscription_id, kind, expr, tz, starts_at, ends_at, location_id, active)
+		SELECT $1::uuid, $2::text, $3::text, $4::text, $5::timestamptz, $6::timestamptz, l.id, true
+		FROM (SELECT NULLIF($7::text, '')::uuid AS id) want
+		LEFT JOIN locations l ON l.id = want.id AND l.subscription_id = $1::uuid
+		WHERE want.id IS NULL OR l.id IS NOT NULL
 		RETURNING id
-	`, subID, cronExpr, tz, startAt, endAt).Scan(&scheduleID)
+	`, subID, kind, s.Expr, s.TZ, s.StartAt, s.EndAt, s.LocationID).Scan(&scheduleID)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("location not found")
+		}
 		return "", fmt.Errorf("insert schedule: %w", err)
 	}
 	return scheduleID, nil
@@ -205,7 +217,8 @@ func (r *PostgresRepo) ListActiveSchedulers(ctx context.Context, chatID int64) (
 	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
 
 	rows, err := r.pool.Query(ctx, `
-		SELECT sc.id, sc.expr, sc.starts_at, sc.ends_at, sc.active, sc.created_at
+		SELECT sc.id, sc.kind, sc.expr, sc.starts_at, sc.ends_at, COALESCE(sc.location_id::text, ''), sc.active, sc.paused,
+		       sc.created_at
 		FROM schedules sc
 		JOIN subscriptions s ON s.id = sc.subscription_id
 		WHERE s.owner_ref=$1 AND s.active=true AND sc.active=true
@@ -220,7 +233,7 @@ func (r *PostgresRepo) ListActiveSchedulers(ctx context.Context, chatID int64) (
 	for rows.Next() {
 		var it domain.Scheduler
 		var startAt, endAt *time.Time
-		err := rows.Scan(&it.ID, &it.Expr, &startAt, &endAt, &it.IsActive, &it.CreatedAt)
+		err := rows.Scan(&it.ID, &it.Kind, &it.Expr, &startAt, &endAt, &it.LocationID, &it.IsActive, &it.Paused, &it.CreatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("scan: %w", err)
 		}
@@ -234,52 +247,49 @@ func (r *PostgresRepo) ListActiveSchedulers(ctx context.Context, chatID int64) (
 	return out, nil
 }
 
-// ListAllActiveSchedulers returns all active schedules with delivery targets for bootstrapping.
-func (r *PostgresRepo) ListAllActiveSchedulers(ctx context.Context) ([]domain.SchedulerWithTarget, error) {
+// SetSchedulerPaused pauses or resumes an active schedule owned by the given chat.
+func (r *PostgresRepo) SetSchedulerPaused(ctx context.Context, chatID int64, schedulerID string, paused bool) error {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+
+	cmdTag, err := r.pool.Exec(ctx, `
+		UPDATE schedules
+		SET paused=$3, updated_at=now()
+		WHERE id=$1 AND active=true
+		  AND subscription_id = (SELECT id FROM subscriptions WHERE owner_ref=$2)
+	`, schedulerID, ownerRef, paused)
+	if err != nil {
+		return fmt.Errorf("pause schedule: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// ListRuns returns the latest runs of a schedule owned by the given chat, newest first.
+func (r *PostgresRepo) ListRuns(ctx context.Context, chatID int64, schedulerID string, limit int) ([]domain.Run, error) {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+
 	rows, err := r.pool.Query(ctx, `
-		SELECT sc.id, sc.subscription_id, sc.kind, sc.expr, sc.tz, sc.starts_at, sc.ends_at, sc.active, sc.created_at,
-		       s.owner_ref, s.lat, s.lon, s.active,
-		       e.kind, e.address
-		FROM schedules sc
-		JOIN subscriptions s ON s.id = sc.subscription_id
-		JOIN subscription_endpoints se ON se.subscription_id = s.id
-		JOIN endpoints e ON e.id = se.endpoint_id
-		WHERE s.active=true AND sc.active=true
-		ORDER BY sc.created_at ASC
-	`)
+		SELECT r.id, r.scheduled_for, r.status, COALESCE(r.error, ''), COALESCE(r.delivery, '')
+		FROM runs r
+		JOIN subscriptions s ON s.id = r.subscription_id
+		WHERE r.schedule_id=$1 AND s.owner_ref=$2
+		ORDER BY r.id DESC
+		LIMIT $3
+	`, schedulerID, ownerRef, limit)
 	if err != nil {
-		return nil, fmt.Errorf("query all active schedules: %w", err)
+		return nil, fmt.Errorf("query runs: %w", err)
 	}
 	defer rows.Close()
 
-	var out []domain.SchedulerWithTarget
+	var out []domain.Run
 	for rows.Next() {
-		var it domain.SchedulerWithTarget
-		var startAt, endAt *time.Time
-		err := rows.Scan(
-			&it.Scheduler.ID,
-			&it.Scheduler.SubscriptionID,
-			&it.Scheduler.Kind,
-			&it.Scheduler.Expr,
-			&it.Scheduler.TZ,
-			&startAt,
-			&endAt,
-			&it.Scheduler.IsActive,
-			&it.Scheduler.CreatedAt,
-			&it.Subscription.OwnerRef,
-			&it.Subscription.Lat,
-			&it.Subscription.Lon,
-			&it.Subscription.IsActive,
-			&it.Target.Kind,
-			&it.Target.Address,
-		)
-		if err != nil {
+		var run domain.Run
+		if err := rows.Scan(&run.ID, &run.ScheduledFor, &run.Status, &run.Error, &run.Delivery); err != nil {
 			return nil, fmt.Errorf("scan: %w", err)
 		}
-		it.Scheduler.StartAt = startAt
-		it.Scheduler.EndAt = endAt
-		it.Subscription.ID = it.Scheduler.SubscriptionID
-		out = append(out, it)
+		out = append(out, run)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("rows: %w", err)
@@ -287,20 +297,27 @@ func (r *PostgresRepo) ListAllActiveSchedulers(ctx context.Context) ([]domain.Sc
 	return out, nil
 }
 
-// GetActiveScheduler loads a single active schedule with its target for runtime registration.
-func (r *PostgresRepo) GetActiveScheduler(ctx context.Context, schedulerID string) (domain.SchedulerWithTarget, error) {
-	var it domain.SchedulerWithTarget
-	var startAt, endAt *time.Time
-	err := r.pool.QueryRow(ctx, `
-		SELECT sc.id, sc.subscription_id, sc.kind, sc.expr, sc.tz, sc.starts_at, sc.ends_at, sc.active, sc.created_at,
-		       s.owner_ref, s.lat, s.lon, s.active,
+// schedulerWithTargetSelect selects a schedule together with its subscription and delivery target.
+// Column order must match scanSchedulerWithTarget.
+const schedulerWithTargetSelect = `
+		SELECT sc.id, sc.subscription_id, sc.kind, sc.expr, sc.tz, sc.starts_at, sc.ends_at, sc.active, sc.paused,
+		       sc.created_at, l.id, l.name, l.lat, l.lon,
+		       s.owner_ref, s.lat, s.lon, s.active, s.parse_mode, COALESCE(s.lang, ''), COALESCE(s.units, ''),
+		       COALESCE(s.tz, ''), s.quiet_start, s.quiet_end, s.min_severity,
 		       e.kind, e.address
 		FROM schedules sc
 		JOIN subscriptions s ON s.id = sc.subscription_id
 		JOIN subscription_endpoints se ON se.subscription_id = s.id
 		JOIN endpoints e ON e.id = se.endpoint_id
-	WHERE sc.id=$1 AND s.active=true AND sc.active=true
-	`, schedulerID).Scan(
+		LEFT JOIN locations l ON l.id = sc.location_id`
+
+func scanSchedulerWithTarget(row pgx.Row) (domain.SchedulerWithTarget, error) {
+	var it domain.SchedulerWithTarget
+	var startAt, endAt *time.Time
+	var locID, locName *string
+	var locLat, locLon *float64
+	var quietStart, quietEnd *int
+	err := row.Scan(
 		&it.Scheduler.ID,
 		&it.Scheduler.SubscriptionID,
 		&it.Scheduler.Kind,
@@ -309,20 +326,79 @@ func (r *PostgresRepo) GetActiveScheduler(ctx context.Context, schedulerID strin
 		&startAt,
 		&endAt,
 		&it.Scheduler.IsActive,
+		&it.Scheduler.Paused,
 		&it.Scheduler.CreatedAt,
+		&locID,
+		&locName,
+		&locLat,
+		&locLon,
 		&it.Subscription.OwnerRef,
 		&it.Subscription.Lat,
 		&it.Subscription.Lon,
 		&it.Subscription.IsActive,
+		&it.Subscription.ParseMode,
+		&it.Subscription.Language,
+		&it.Subscription.Units,
+		&it.Subscription.TZ,
+		&quietStart,
+		&quietEnd,
+		&it.Subscription.MinSeverity,
 		&it.Target.Kind,
 		&it.Target.Address,
 	)
 	if err != nil {
-		return domain.SchedulerWithTarget{}, fmt.Errorf("get active schedule: %w", err)
+		return domain.SchedulerWithTarget{}, err
 	}
 	it.Scheduler.StartAt = startAt
 	it.Scheduler.EndAt = endAt
 	it.Subscription.ID = it.Scheduler.SubscriptionID
+	it.Subscription.Quiet = quietHours(quietStart, quietEnd)
+	if locID != nil {
+		it.Scheduler.LocationID = *locID
+		it.Location = &domain.Location{
+			ID:             *locID,
+			SubscriptionID: it.Scheduler.SubscriptionID,
+			Name:           *locName,
+			Lat:            *locLat,
+			Lon:            *locLon,
+		}
+	}
+	return it, nil
+}
+
+// ListAllActiveSchedulers returns all active, not paused schedules with delivery targets for bootstrapping.
+func (r *PostgresRepo) ListAllActiveSchedulers(ctx context.Context) ([]domain.SchedulerWithTarget, error) {
+	rows, err := r.pool.Query(ctx, schedulerWithTargetSelect+`
+		WHERE s.active=true AND sc.active=true AND sc.paused=false
+		ORDER BY sc.created_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query all active schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.SchedulerWithTarget
+	for rows.Next() {
+		it, err := scanSchedulerWithTarget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return out, nil
+}
+
+// GetActiveScheduler loads a single active schedule with its target for runtime registration.
+func (r *PostgresRepo) GetActiveScheduler(ctx context.Context, schedulerID string) (domain.SchedulerWithTarget, error) {
+	it, err := scanSchedulerWithTarget(r.pool.QueryRow(ctx, schedulerWithTargetSelect+`
+		WHERE sc.id=$1 AND s.active=true AND sc.active=true
+	`, schedulerID))
+	if err != nil {
+		return domain.SchedulerWithTarget{}, fmt.Errorf("get active schedule: %w", err)
+	}
 	return it, nil
 }
 
@@ -344,18 +420,158 @@ func (r *PostgresRepo) UpdateSchedulerNextRunAt(ctx context.Context, schedulerID
 	return nil
 }
 
-// InsertRun records one schedule execution attempt.
-func (r *PostgresRepo) InsertRun(ctx context.Context, subscriptionID, schedulerID string, scheduledFor time.Time, status, payload, errText string) error {
-	// scheduler_id is not stored directly in schema; payload can carry it.
-	if payload == "" {
-		payload = fmt.Sprintf("scheduler_id=%s", schedulerID)
+// InsertRun records one schedule execution attempt and returns its ID.
+func (r *PostgresRepo) InsertRun(ctx context.Context, subscriptionID, schedulerID string, scheduledFor time.Time, status, payload, errText string) (int64, error) {
+	var id int64
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO runs(subscription_id, schedule_id, scheduled_for, finished_at, status, payload, error)
+		VALUES($1, NULLIF($2, '')::uuid, $3, now(), $4, $5, NULLIF($6, ''))
+		RETURNING id
+	`, subscriptionID, schedulerID, scheduledFor, status, payload, errText).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("insert run: %w", err)
+	}
+	return id, nil
+}
+
+// FailRunDelivery marks a run whose messages could not be handed off as failed.
+func (r *PostgresRepo) FailRunDelivery(ctx context.Context, runID int64, errText string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE runs SET status='error', error=$2, delivery='failed', delivery_error=$2
+		WHERE id=$1
+	`, runID, errText)
+	if err != nil {
+		return fmt.Errorf("fail run delivery: %w", err)
+	}
+	return nil
+}
+
+// EnqueueMessages adds messages to the outbox in one transaction, so parts of a split
+// message are queued together or not at all, and marks their run delivery as pending.
+func (r *PostgresRepo) EnqueueMessages(ctx context.Context, msgs []domain.OutboxMessage) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, m := range msgs {
+		var runID *int64
+		if m.RunID != 0 {
+			runID = &m.RunID
+		}
+		_, err := tx.Exec(ctx, `
+			WITH m AS (
+				INSERT INTO outbox(run_id, chat_id, text, parse_mode, reply_markup, edit_message_id)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING run_id
+			)
+			UPDATE runs SET delivery='pending'
+			FROM m
+			WHERE runs.id = m.run_id AND runs.delivery IS NULL
+		`, runID, m.ChatID, m.Text, m.ParseMode, m.ReplyMarkup, m.EditMessageID)
+		if err != nil {
+			return fmt.Errorf("enqueue message: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// ClaimOutbox leases up to limit due messages until leaseUntil, oldest first.
+// Only the oldest pending message of each chat is eligible, which keeps per-chat order.
+func (r *PostgresRepo) ClaimOutbox(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]domain.OutboxMessage, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH claimed AS (
+			UPDATE outbox SET next_attempt_at=$3
+			WHERE id IN (
+				SELECT o.id FROM outbox o
+				WHERE o.state='pending' AND o.next_attempt_at <= $1
+				  AND NOT EXISTS (
+					SELECT 1 FROM outbox p WHERE p.chat_id=o.chat_id AND p.state='pending' AND p.id < o.id
+				  )
+				ORDER BY o.id
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, COALESCE(run_id, 0), chat_id, text, parse_mode, reply_markup, edit_message_id, attempts
+		)
+		SELECT * FROM claimed ORDER BY id
+	`, now, limit, leaseUntil)
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.OutboxMessage
+	for rows.Next() {
+		var m domain.OutboxMessage
+		if err := rows.Scan(&m.ID, &m.RunID, &m.ChatID, &m.Text, &m.ParseMode, &m.ReplyMarkup, &m.EditMessageID, &m.Attempts); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return out, nil
+}
+
+// DeferOutbox postpones a message without counting a failed attempt (e.g. rate limited locally).
+func (r *PostgresRepo) DeferOutbox(ctx context.Context, id int64, at time.Time) error {
+	if _, err := r.pool.Exec(ctx, `UPDATE outbox SET next_attempt_at=$2 WHERE id=$1`, id, at); err != nil {
+		return fmt.Errorf("defer outbox message: %w", err)
+	}
+	return nil
+}
+
+// RetryOutbox records a failed attempt and schedules the next one.
+func (r *PostgresRepo) RetryOutbox(ctx context.Context, id int64, at time.Time, errText string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE outbox SET attempts=attempts+1, next_attempt_at=$2, last_error=$3 WHERE id=$1
+	`, id, at, errText)
+	if err != nil {
+		return fmt.Errorf("retry outbox message: %w", err)
 	}
+	return nil
+}
+
+// CompleteOutbox removes a delivered message; the run is marked delivered with its last message.
+func (r *PostgresRepo) CompleteOutbox(ctx context.Context, id int64) error {
 	_, err := r.pool.Exec(ctx, `
-		INSERT INTO runs(subscription_id, scheduled_for, finished_at, status, payload, error)
-		VALUES($1, $2, now(), $3, $4, NULLIF($5, ''))
-	`, subscriptionID, scheduledFor, status, payload, errText)
+		WITH m AS (
+			DELETE FROM outbox WHERE id=$1 RETURNING run_id
+		)
+		UPDATE runs SET delivery='delivered', delivered_at=now()
+		FROM m
+		WHERE runs.id = m.run_id AND runs.delivery='pending'
+		  AND NOT EXISTS (
+			SELECT 1 FROM outbox o WHERE o.run_id=m.run_id AND o.state='pending' AND o.id <> $1
+		  )
+	`, id)
 	if err != nil {
-		return fmt.Errorf("insert run: %w", err)
+		return fmt.Errorf("complete outbox message: %w", err)
+	}
+	return nil
+}
+
+// FailOutbox gives up on a message and marks its run delivery as failed.
+func (r *PostgresRepo) FailOutbox(ctx context.Context, id int64, errText string) error {
+	_, err := r.pool.Exec(ctx, `
+		WITH m AS (
+			UPDATE outbox SET state='failed', attempts=attempts+1, last_error=$2
+			WHERE id=$1
+			RETURNING run_id
+		)
+		UPDATE runs SET delivery='failed', delivery_error=$2
+		FROM m
+		WHERE runs.id = m.run_id
+	`, id, errText)
+	if err != nil {
+		return fmt.Errorf("fail outbox message: %w", err)
 	}
 	return nil
 }
@@ -381,20 +597,238 @@ func (r *PostgresRepo) ReserveDailyUsage(ctx context.Context, subscriptionID str
 	return true, used, nil
 }
 
-// MarkAlertSent stores the alert fingerprint if it has not been sent yet.
+// GetDailyUsage returns the number of API calls made for the chat subscription on the given day.
+func (r *PostgresRepo) GetDailyUsage(ctx context.Context, chatID int64, day time.Time) (int, error) {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	dayKey := day.UTC().Format("2006-01-02")
+	var used int
+	err := r.pool.QueryRow(ctx, `
+		SELECT COALESCE(SUM(u.used), 0)
+		FROM daily_usage u
+		JOIN subscriptions s ON s.id = u.subscription_id
+		WHERE s.owner_ref=$1 AND u.day=$2::date
+	`, ownerRef, dayKey).Scan(&used)
+	if err != nil {
+		return 0, fmt.Errorf("get daily usage: %w", err)
+	}
+	return used, nil
+}
+
+// ReserveBudget atomically reserves one API call from the account-wide daily and monthly budget.
+// Nothing is reserved if either limit is reached (a zero limit means unlimited).
+// The returned usage reflects the state after the reservation attempt.
+func (r *PostgresRepo) ReserveBudget(ctx context.Context, now time.Time, dayLimit, monthLimit int) (domain.BudgetUsage, bool, error) {
+	day, month := domain.BudgetPeriods(now)
+	u := domain.BudgetUsage{Day: day, Month: month, DayLimit: dayLimit, MonthLimit: monthLimit}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return u, false, fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO api_budget(period) VALUES($1), ($2)
+		ON CONFLICT (period) DO NOTHING
+	`, day, month); err != nil {
+		return u, false, fmt.Errorf("init budget: %w", err)
+	}
+	if err := tx.QueryRow(ctx, `
+		SELECT
+			(SELECT used FROM api_budget WHERE period=$1 FOR UPDATE),
+			(SELECT used FROM api_budget WHERE period=$2 FOR UPDATE)
+	`, day, month).Scan(&u.DayUsed, &u.MonthUsed); err != nil {
+		return u, false, fmt.Errorf("lock budget: %w", err)
+	}
+
+	if (dayLimit > 0 && u.DayUsed >= dayLimit) || (monthLimit > 0 && u.MonthUsed >= monthLimit) {
+		return u, false, nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE api_budget SET used = used + 1 WHERE period IN ($1, $2)
+	`, day, month); err != nil {
+		return u, false, fmt.Errorf("reserve budget: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return u, false, fmt.Errorf("commit: %w", err)
+	}
+	u.DayUsed++
+	u.MonthUsed++
+	return u, true, nil
+}
+
+// GetBudgetUsage returns the account-wide API usage for the current day and month (limits are left zero).
+func (r *PostgresRepo) GetBudgetUsage(ctx context.Context, now time.Time) (domain.BudgetUsage, error) {
+	day, month := domain.BudgetPeriods(now)
+	u := domain.BudgetUsage{Day: day, Month: month}
+	err := r.pool.QueryRow(ctx, `
+		SELECT
+			COALESCE((SELECT used FROM api_budget WHERE period=$1), 0),
+			COALESCE((SELECT used FROM api_budget WHERE period=$2), 0)
+	`, day, month).Scan(&u.DayUsed, &u.MonthUsed)
+	if err != nil {
+		return u, fmt.Errorf("get budget usage: %w", err)
+	}
+	return u, nil
+}
+
+// MarkBudgetNotified records that the admin was notified about pct usage of the period.
+// It returns true only for the first caller reaching a new threshold.
+func (r *PostgresRepo) MarkBudgetNotified(ctx context.Context, period string, pct int) (bool, error) {
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE api_budget SET notified_pct=$2
+		WHERE period=$1 AND notified_pct < $2
+	`, period, pct)
+	if err != nil {
+		return false, fmt.Errorf("mark budget notified: %w", err)
+	}
+	return cmd.RowsAffected() > 0, nil
+}
+
+// GetWeatherCache returns a shared cached API response or storage.ErrNotFound.
+func (r *PostgresRepo) GetWeatherCache(ctx context.Context, key string) ([]byte, time.Time, error) {
+	var body []byte
+	var fetchedAt time.Time
+	err := r.pool.QueryRow(ctx, `
+		SELECT body, fetched_at FROM weather_cache WHERE key=$1
+	`, key).Scan(&body, &fetchedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, time.Time{}, storage.ErrNotFound
+		}
+		return nil, time.Time{}, fmt.Errorf("get weather cache: %w", err)
+	}
+	return body, fetchedAt, nil
+}
+
+// PutWeatherCache stores a shared API response unless a newer one is already stored.
+func (r *PostgresRepo) PutWeatherCache(ctx context.Context, key string, body []byte, fetchedAt time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO weather_cache(key, body, fetched_at)
+		VALUES($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE
+		SET body = EXCLUDED.body, fetched_at = EXCLUDED.fetched_at
+		WHERE weather_cache.fetched_at < EXCLUDED.fetched_at
+	`, key, body, fetchedAt)
+	if err != nil {
+		return fmt.Errorf("put weather cache: %w", err)
+	}
+	return nil
+}
+
+// MarkAlertSent stores the alert fingerprint for the location if it has not been sent yet.
 // It returns true if the fingerprint was inserted (i.e. the alert is new).
-func (r *PostgresRepo) MarkAlertSent(ctx context.Context, subscriptionID string, fingerprint string) (bool, error) {
+// locationKey scopes dedup per location ("" means subscription coordinates).
+func (r *PostgresRepo) MarkAlertSent(ctx context.Context, subscriptionID, locationKey, fingerprint string) (bool, error) {
 	cmd, err := r.pool.Exec(ctx, `
-		INSERT INTO sent_alerts(subscription_id, fingerprint)
-		VALUES($1, $2)
-		ON CONFLICT (subscription_id, fingerprint) DO NOTHING
-	`, subscriptionID, fingerprint)
+		INSERT INTO sent_alerts(subscription_id, location_key, fingerprint)
+		VALUES($1, $2, $3)
+		ON CONFLICT (subscription_id, location_key, fingerprint) DO NOTHING
+	`, subscriptionID, locationKey, fingerprint)
 	if err != nil {
 		return false, fmt.Errorf("mark alert sent: %w", err)
 	}
 	return cmd.RowsAffected() > 0, nil
 }
 
+// ClearAlertSent removes a stored fingerprint so the same notification can be delivered again.
+// It returns true if the fingerprint existed.
+func (r *PostgresRepo) ClearAlertSent(ctx context.Context, subscriptionID, locationKey, fingerprint string) (bool, error) {
+	cmd, err := r.pool.Exec(ctx, `
+		DELETE FROM sent_alerts
+		WHERE subscription_id=$1 AND location_key=$2 AND fingerprint=$3
+	`, subscriptionID, locationKey, fingerprint)
+	if err != nil {
+		return false, fmt.Errorf("clear alert sent: %w", err)
+	}
+	return cmd.RowsAffected() > 0, nil
+}
+
+// PruneAlertsSent removes fingerprints starting with prefix that were stored before the given time.
+func (r *PostgresRepo) PruneAlertsSent(ctx context.Context, subscriptionID, locationKey, prefix string, before time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM sent_alerts
+		WHERE subscription_id=$1 AND location_key=$2 AND starts_with(fingerprint, $3) AND sent_at < $4
+	`, subscriptionID, locationKey, prefix, before)
+	if err != nil {
+		return fmt.Errorf("prune alerts sent: %w", err)
+	}
+	return nil
+}
+
+// HasAlertSent reports whether the fingerprint is stored for the location.
+func (r *PostgresRepo) HasAlertSent(ctx context.Context, subscriptionID, locationKey, fingerprint string) (bool, error) {
+	var sent bool
+	err := r.pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM sent_alerts
+			WHERE subscription_id=$1 AND location_key=$2 AND fingerprint=$3
+		)
+	`, subscriptionID, locationKey, fingerprint).Scan(&sent)
+	if err != nil {
+		return false, fmt.Errorf("has alert sent: %w", err)
+	}
+	return sent, nil
+}
+
+// ListAlertStates returns the active alerts of the location and the stored state of keys,
+// active or not, by alert key.
+func (r *PostgresRepo) ListAlertStates(ctx context.Context, subscriptionID, locationKey string, keys []string) (map[string]domain.AlertState, error) {
+	if keys == nil {
+		keys = []string{}
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT alert_key, snapshot, active
+		FROM alert_state
+		WHERE subscription_id=$1 AND location_key=$2 AND (active OR alert_key = ANY($3))
+	`, subscriptionID, locationKey, keys)
+	if err != nil {
+		return nil, fmt.Errorf("list alert states: %w", err)
+	}
+	defer rows.Close()
+
+	out := map[string]domain.AlertState{}
+	for rows.Next() {
+		var st domain.AlertState
+		if err := rows.Scan(&st.Key, &st.Snapshot, &st.Active); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out[st.Key] = st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return out, nil
+}
+
+// SaveAlertState stores the latest delivered snapshot for an alert identity and marks it active.
+func (r *PostgresRepo) SaveAlertState(ctx context.Context, subscriptionID, locationKey, alertKey, snapshot string) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO alert_state(subscription_id, location_key, alert_key, snapshot, active)
+		VALUES($1, $2, $3, $4, true)
+		ON CONFLICT (subscription_id, location_key, alert_key) DO UPDATE
+		SET snapshot = EXCLUDED.snapshot, active = true, updated_at = now()
+	`, subscriptionID, locationKey, alertKey, snapshot)
+	if err != nil {
+		return fmt.Errorf("save alert state: %w", err)
+	}
+	return nil
+}
+
+// EndAlerts deactivates the alerts with the given keys.
+func (r *PostgresRepo) EndAlerts(ctx context.Context, subscriptionID, locationKey string, keys []string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE alert_state
+		SET active=false, updated_at=now()
+		WHERE subscription_id=$1 AND location_key=$2 AND active=true AND alert_key = ANY($3)
+	`, subscriptionID, locationKey, keys)
+	if err != nil {
+		return fmt.Errorf("end alerts: %w", err)
+	}
+	return nil
+}
+
 // SetSubscriptionLocation updates coordinates for the chat subscription.
 func (r *PostgresRepo) SetSubscriptionLocation(ctx context.Context, chatID int64, lat, lon float64) error {
 	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
@@ -412,6 +846,440 @@ func (r *PostgresRepo) SetSubscriptionLocation(ctx context.Context, chatID int64
 	return nil
 }
 
+// AddLocation creates or updates a named location of the chat subscription.
+func (r *PostgresRepo) AddLocation(ctx context.Context, chatID int64, name string, lat, lon float64) (domain.Location, error) {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	loc := domain.Location{Name: name, Lat: lat, Lon: lon}
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO locations(subscription_id, name, lat, lon)
+		SELECT id, $2::text, $3::double precision, $4::double precision FROM subscriptions WHERE owner_ref=$1
+		ON CONFLICT (subscription_id, name) DO UPDATE
+		SET lat = EXCLUDED.lat, lon = EXCLUDED.lon
+		RETURNING id, subscription_id
+	`, ownerRef, name, lat, lon).Scan(&loc.ID, &loc.SubscriptionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Location{}, fmt.Errorf("subscription not found")
+		}
+		return domain.Location{}, fmt.Errorf("add location: %w", err)
+	}
+	return loc, nil
+}
+
+// ListLocations returns named locations of the chat subscription ordered by name.
+func (r *PostgresRepo) ListLocations(ctx context.Context, chatID int64) ([]domain.Location, error) {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	rows, err := r.pool.Query(ctx, `
+		SELECT l.id, l.subscription_id, l.name, l.lat, l.lon
+		FROM locations l
+		JOIN subscriptions s ON s.id = l.subscription_id
+		WHERE s.owner_ref=$1
+		ORDER BY l.name ASC
+	`, ownerRef)
+	if err != nil {
+		return nil, fmt.Errorf("query locations: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.Location
+	for rows.Next() {
+		var loc domain.Location
+		if err := rows.Scan(&loc.ID, &loc.SubscriptionID, &loc.Name, &loc.Lat, &loc.Lon); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, loc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return out, nil
+}
+
+// GetLocation returns a named location of the chat subscription or storage.ErrNotFound.
+func (r *PostgresRepo) GetLocation(ctx context.Context, chatID int64, name string) (domain.Location, error) {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	var loc domain.Location
+	err := r.pool.QueryRow(ctx, `
+		SELECT l.id, l.subscription_id, l.name, l.lat, l.lon
+		FROM locations l
+		JOIN subscriptions s ON s.id = l.subscription_id
+		WHERE s.owner_ref=$1 AND l.name=$2
+	`, ownerRef, name).Scan(&loc.ID, &loc.SubscriptionID, &loc.Name, &loc.Lat, &loc.Lon)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Location{}, storage.ErrNotFound
+		}
+		return domain.Location{}, fmt.Errorf("get location: %w", err)
+	}
+	return loc, nil
+}
+
+// RemoveLocation deletes a named location of the chat subscription.
+// It returns storage.ErrInUse while active schedules target the location.
+func (r *PostgresRepo) RemoveLocation(ctx context.Context, chatID int64, name string) error {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var locID string
+	var inUse bool
+	err = tx.QueryRow(ctx, `
+		SELECT l.id, EXISTS (SELECT 1 FROM schedules sc WHERE sc.location_id = l.id AND sc.active=true)
+		FROM locations l
+		JOIN subscriptions s ON s.id = l.subscription_id
+		WHERE s.owner_ref=$1 AND l.name=$2
+		FOR UPDATE OF l
+	`, ownerRef, name).Scan(&locID, &inUse)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+		return fmt.Errorf("get location: %w", err)
+	}
+	if inUse {
+		return storage.ErrInUse
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM locations WHERE id=$1`, locID); err != nil {
+		return fmt.Errorf("delete location: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// GetSubscription returns the subscription of the chat or storage.ErrNotFound.
+func (r *PostgresRepo) GetSubscription(ctx context.Context, chatID int64) (domain.Subscription, error) {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	var sub domain.Subscription
+	var quietStart, quietEnd *int
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, owner_ref, lat, lon, active, parse_mode, COALESCE(lang, ''), COALESCE(units, ''),
+		       COALESCE(tz, ''), quiet_start, quiet_end, min_severity
+		FROM subscriptions
+		WHERE owner_ref=$1
+	`, ownerRef).Scan(&sub.ID, &sub.OwnerRef, &sub.Lat, &sub.Lon, &sub.IsActive, &sub.ParseMode, &sub.Language, &sub.Units,
+		&sub.TZ, &quietStart, &quietEnd, &sub.MinSeverity)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Subscription{}, storage.ErrNotFound
+		}
+		return domain.Subscription{}, fmt.Errorf("get subscription: %w", err)
+	}
+	sub.Quiet = quietHours(quietStart, quietEnd)
+	return sub, nil
+}
+
+// quietHours builds quiet hours from nullable columns.
+func quietHours(start, end *int) *domain.QuietHours {
+	if start == nil || end == nil {
+		return nil
+	}
+	return &domain.QuietHours{Start: *start, End: *end}
+}
+
+// SetQuietHours sets the quiet hours (nil disables them) and, when tz is not empty,
+// the time zone of the chat subscription.
+func (r *PostgresRepo) SetQuietHours(ctx context.Context, chatID int64, q *domain.QuietHours, tz string) error {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	var start, end *int
+	if q != nil {
+		start, end = &q.Start, &q.End
+	}
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE subscriptions
+		SET quiet_start=$2, quiet_end=$3, tz=COALESCE(NULLIF($4, ''), tz), updated_at=now()
+		WHERE owner_ref=$1
+	`, ownerRef, start, end, tz)
+	if err != nil {
+		return fmt.Errorf("set quiet hours: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+	return nil
+}
+
+// SetMinSeverity sets the minimum alert severity of the chat subscription.
+func (r *PostgresRepo) SetMinSeverity(ctx context.Context, chatID int64, severity domain.Severity) error {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE subscriptions
+		SET min_severity=$2, updated_at=now()
+		WHERE owner_ref=$1
+	`, ownerRef, int(severity))
+	if err != nil {
+		return fmt.Errorf("set min severity: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+	return nil
+}
+
+// HoldMessages stores task messages until quiet hours of their subscription end.
+// The messages of a run are stored together or not at all.
+func (r *PostgresRepo) HoldMessages(ctx context.Context, msgs []domain.HeldMessage) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, m := range msgs {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO held_messages(subscription_id, target_kind, target_address, text, parse_mode)
+			VALUES ($1, $2, $3, $4, $5)
+		`, m.SubscriptionID, m.Target.Kind, m.Target.Address, m.Text, m.ParseMode)
+		if err != nil {
+			return fmt.Errorf("hold message: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// ListHeldSubscriptions returns subscriptions that have held messages.
+func (r *PostgresRepo) ListHeldSubscriptions(ctx context.Context) ([]domain.Subscription, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT s.id, s.owner_ref, COALESCE(s.lang, ''), COALESCE(s.tz, ''), s.quiet_start, s.quiet_end
+		FROM subscriptions s
+		WHERE EXISTS (SELECT 1 FROM held_messages h WHERE h.subscription_id = s.id)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query held subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.Subscription
+	for rows.Next() {
+		var sub domain.Subscription
+		var quietStart, quietEnd *int
+		if err := rows.Scan(&sub.ID, &sub.OwnerRef, &sub.Language, &sub.TZ, &quietStart, &quietEnd); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		sub.Quiet = quietHours(quietStart, quietEnd)
+		out = append(out, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return out, nil
+}
+
+// ClaimHeldMessages returns due held messages of a subscription in arrival order and leases
+// them until leaseUntil, so other replicas skip them while they are being delivered.
+func (r *PostgresRepo) ClaimHeldMessages(ctx context.Context, subscriptionID string, now, leaseUntil time.Time) ([]domain.HeldMessage, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH claimed AS (
+			UPDATE held_messages SET next_attempt_at=$3
+			WHERE id IN (
+				SELECT id FROM held_messages
+				WHERE subscription_id=$1 AND next_attempt_at <= $2
+				ORDER BY id
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, subscription_id, target_kind, target_address, text, parse_mode, attempts, created_at
+		)
+		SELECT * FROM claimed ORDER BY id
+	`, subscriptionID, now, leaseUntil)
+	if err != nil {
+		return nil, fmt.Errorf("claim held messages: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.HeldMessage
+	for rows.Next() {
+		var m domain.HeldMessage
+		if err := rows.Scan(&m.ID, &m.SubscriptionID, &m.Target.Kind, &m.Target.Address, &m.Text, &m.ParseMode, &m.Attempts, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return out, nil
+}
+
+// DeferHeldMessages postpones held messages without counting a failed attempt.
+func (r *PostgresRepo) DeferHeldMessages(ctx context.Context, ids []int64, at time.Time) error {
+	if _, err := r.pool.Exec(ctx, `UPDATE held_messages SET next_attempt_at=$2 WHERE id = ANY($1)`, ids, at); err != nil {
+		return fmt.Errorf("defer held messages: %w", err)
+	}
+	return nil
+}
+
+// RetryHeldMessages counts a failed delivery of the messages and schedules the next one at at.
+func (r *PostgresRepo) RetryHeldMessages(ctx context.Context, ids []int64, at time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE held_messages SET attempts=attempts+1, next_attempt_at=$2
+		WHERE id = ANY($1)
+	`, ids, at)
+	if err != nil {
+		return fmt.Errorf("retry held messages: %w", err)
+	}
+	return nil
+}
+
+// DeleteHeldMessages removes delivered or dropped held messages.
+func (r *PostgresRepo) DeleteHeldMessages(ctx context.Context, ids []int64) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM held_messages WHERE id = ANY($1)`, ids); err != nil {
+		return fmt.Errorf("delete held messages: %w", err)
+	}
+	return nil
+}
+
+// SetSubscriptionLanguage sets the language of the chat subscription.
+func (r *PostgresRepo) SetSubscriptionLanguage(ctx context.Context, chatID int64, lang string) error {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE subscriptions
+		SET lang=$2, updated_at=now()
+		WHERE owner_ref=$1
+	`, ownerRef, lang)
+	if err != nil {
+		return fmt.Errorf("set subscription language: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+	return nil
+}
+
+// SetSubscriptionUnits sets the measurement units of the chat subscription.
+func (r *PostgresRepo) SetSubscriptionUnits(ctx context.Context, chatID int64, units string) error {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE subscriptions
+		SET units=$2, updated_at=now()
+		WHERE owner_ref=$1
+	`, ownerRef, units)
+	if err != nil {
+		return fmt.Errorf("set subscription units: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+	return nil
+}
+
+// SetParseMode sets the parse mode used for task messages of the chat subscription.
+func (r *PostgresRepo) SetParseMode(ctx context.Context, chatID int64, parseMode string) error {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	cmd, err := r.pool.Exec(ctx, `
+		UPDATE subscriptions
+		SET parse_mode=$2, updated_at=now()
+		WHERE owner_ref=$1
+	`, ownerRef, parseMode)
+	if err != nil {
+		return fmt.Errorf("set parse mode: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+	return nil
+}
+
+// SetTemplate stores a message template override for the chat subscription.
+func (r *PostgresRepo) SetTemplate(ctx context.Context, chatID int64, name, body string) error {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	cmd, err := r.pool.Exec(ctx, `
+		INSERT INTO message_templates(subscription_id, name, body)
+		SELECT id, $2, $3 FROM subscriptions WHERE owner_ref=$1
+		ON CONFLICT (subscription_id, name) DO UPDATE
+		SET body = EXCLUDED.body, updated_at = now()
+	`, ownerRef, name, body)
+	if err != nil {
+		return fmt.Errorf("set template: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return fmt.Errorf("subscription not found")
+	}
+	return nil
+}
+
+// ResetTemplate removes a message template override for the chat subscription.
+func (r *PostgresRepo) ResetTemplate(ctx context.Context, chatID int64, name string) error {
+	ownerRef := fmt.Sprintf("telegram:chat:%d", chatID)
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM message_templates
+		WHERE name=$2
+		  AND subscription_id = (SELECT id FROM subscriptions WHERE owner_ref=$1)
+	`, ownerRef, name)
+	if err != nil {
+		return fmt.Errorf("reset template: %w", err)
+	}
+	return nil
+}
+
+// ListTemplates returns message template overrides of a subscription keyed by template name.
+func (r *PostgresRepo) ListTemplates(ctx context.Context, subscriptionID string) (map[string]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT name, body FROM message_templates WHERE subscription_id=$1
+	`, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("query templates: %w", err)
+	}
+	defer rows.Close()
+
+	out := map[string]string{}
+	for rows.Next() {
+		var name, body string
+		if err := rows.Scan(&name, &body); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out[name] = body
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+	return out, nil
+}
+
+// GetWizard returns the /new wizard state of the chat or storage.ErrNotFound.
+func (r *PostgresRepo) GetWizard(ctx context.Context, chatID int64) (domain.Wizard, error) {
+	w := domain.Wizard{ChatID: chatID}
+	err := r.pool.QueryRow(ctx, `
+		SELECT step, kind, location_id, expr, updated_at FROM wizard_state WHERE chat_id=$1
+	`, chatID).Scan(&w.Step, &w.Kind, &w.LocationID, &w.Expr, &w.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Wizard{}, storage.ErrNotFound
+	}
+	if err != nil {
+		return domain.Wizard{}, fmt.Errorf("get wizard: %w", err)
+	}
+	return w, nil
+}
+
+// SaveWizard creates or replaces the /new wizard state of the chat.
+func (r *PostgresRepo) SaveWizard(ctx context.Context, w domain.Wizard) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO wizard_state(chat_id, step, kind, location_id, expr, updated_at)
+		VALUES ($1, $2, $3, $4, $5, now())
+		ON CONFLICT (chat_id) DO UPDATE
+		SET step=EXCLUDED.step, kind=EXCLUDED.kind, location_id=EXCLUDED.location_id, expr=EXCLUDED.expr,
+		    updated_at=now()
+	`, w.ChatID, w.Step, w.Kind, w.LocationID, w.Expr)
+	if err != nil {
+		return fmt.Errorf("save wizard: %w", err)
+	}
+	return nil
+}
+
+// DeleteWizard removes the /new wizard state of the chat.
+func (r *PostgresRepo) DeleteWizard(ctx context.Context, chatID int64) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM wizard_state WHERE chat_id=$1`, chatID); err != nil {
+		return fmt.Errorf("delete wizard: %w", err)
+	}
+	return nil
+}
+
 func upsertEndpoint(ctx context.Context, tx pgx.Tx, kind, address string) (string, error) {
 	var endpointID string
 
@@ -3,31 +3,92 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"cron-weather/internal/domain"
 )
 
+var (
+	// ErrNotFound is returned when a requested entity does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrInUse is returned when an entity cannot be removed because others reference it.
+	ErrInUse = errors.New("in use")
+)
+
 // Repo defines persistence operations required by the application and scheduler runtime.
 type Repo interface {
 	ActiveSubscription(ctx context.Context, chatID int64) (string, error)
 	DeactivateSubscription(ctx context.Context, chatID int64) error
+	GetSubscription(ctx context.Context, chatID int64) (domain.Subscription, error)
+	SetSubscriptionLanguage(ctx context.Context, chatID int64, lang string) error
+	SetSubscriptionUnits(ctx context.Context, chatID int64, units string) error
+	SetQuietHours(ctx context.Context, chatID int64, q *domain.QuietHours, tz string) error
+	SetMinSeverity(ctx context.Context, chatID int64, severity domain.Severity) error
 
-	CreateScheduler(ctx context.Context, chatID int64, cronExpr string, tz string, startAt, endAt *time.Time) (string, error)
+	CreateScheduler(ctx context.Context, chatID int64, s domain.Scheduler) (string, error)
 	StopScheduler(ctx context.Context, chatID int64, schedulerID string) error
 	ListActiveSchedulers(ctx context.Context, chatID int64) ([]domain.Scheduler, error)
+	SetSchedulerPaused(ctx context.Context, chatID int64, schedulerID string, paused bool) error
+	ListRuns(ctx context.Context, chatID int64, schedulerID string, limit int) ([]domain.Run, error)
 
 	// Runtime scheduler support
 	ListAllActiveSchedulers(ctx context.Context) ([]domain.SchedulerWithTarget, error)
 	GetActiveScheduler(ctx context.Context, schedulerID string) (domain.SchedulerWithTarget, error)
 	DeactivateScheduler(ctx context.Context, schedulerID string) error
 	UpdateSchedulerNextRunAt(ctx context.Context, schedulerID string, nextRunAt *time.Time) error
-	InsertRun(ctx context.Context, subscriptionID, schedulerID string, scheduledFor time.Time, status, payload, errText string) error
+	InsertRun(ctx context.Context, subscriptionID, schedulerID string, scheduledFor time.Time, status, payload, errText string) (runID int64, err error)
+	FailRunDelivery(ctx context.Context, runID int64, errText string) error
 
 	// Weather task support
 	ReserveDailyUsage(ctx context.Context, subscriptionID string, day time.Time, limit int) (ok bool, used int, err error)
-	MarkAlertSent(ctx context.Context, subscriptionID string, fingerprint string) (inserted bool, err error)
+	GetDailyUsage(ctx context.Context, chatID int64, day time.Time) (int, error)
+	ReserveBudget(ctx context.Context, now time.Time, dayLimit, monthLimit int) (usage domain.BudgetUsage, ok bool, err error)
+	GetBudgetUsage(ctx context.Context, now time.Time) (domain.BudgetUsage, error)
+	MarkBudgetNotified(ctx context.Context, period string, pct int) (marked bool, err error)
+	MarkAlertSent(ctx context.Context, subscriptionID, locationKey, fingerprint string) (inserted bool, err error)
+	ClearAlertSent(ctx context.Context, subscriptionID, locationKey, fingerprint string) (deleted bool, err error)
+	PruneAlertsSent(ctx context.Context, subscriptionID, locationKey, prefix string, before time.Time) error
+	HasAlertSent(ctx context.Context, subscriptionID, locationKey, fingerprint string) (bool, error)
+	ListAlertStates(ctx context.Context, subscriptionID, locationKey string, keys []string) (map[string]domain.AlertState, error)
+	SaveAlertState(ctx context.Context, subscriptionID, locationKey, alertKey, snapshot string) error
+	EndAlerts(ctx context.Context, subscriptionID, locationKey string, keys []string) error
 	SetSubscriptionLocation(ctx context.Context, chatID int64, lat, lon float64) error
+	GetWeatherCache(ctx context.Context, key string) (body []byte, fetchedAt time.Time, err error)
+	PutWeatherCache(ctx context.Context, key string, body []byte, fetchedAt time.Time) error
+
+	// Outbound message queue
+	EnqueueMessages(ctx context.Context, msgs []domain.OutboxMessage) error
+	ClaimOutbox(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]domain.OutboxMessage, error)
+	DeferOutbox(ctx context.Context, id int64, at time.Time) error
+	RetryOutbox(ctx context.Context, id int64, at time.Time, errText string) error
+	CompleteOutbox(ctx context.Context, id int64) error
+	FailOutbox(ctx context.Context, id int64, errText string) error
+
+	// Quiet hours
+	HoldMessages(ctx context.Context, msgs []domain.HeldMessage) error
+	ListHeldSubscriptions(ctx context.Context) ([]domain.Subscription, error)
+	ClaimHeldMessages(ctx context.Context, subscriptionID string, now, leaseUntil time.Time) ([]domain.HeldMessage, error)
+	DeferHeldMessages(ctx context.Context, ids []int64, at time.Time) error
+	RetryHeldMessages(ctx context.Context, ids []int64, at time.Time) error
+	DeleteHeldMessages(ctx context.Context, ids []int64) error
+
+	// Named locations
+	AddLocation(ctx context.Context, chatID int64, name string, lat, lon float64) (domain.Location, error)
+	ListLocations(ctx context.Context, chatID int64) ([]domain.Location, error)
+	GetLocation(ctx context.Context, chatID int64, name string) (domain.Location, error)
+	RemoveLocation(ctx context.Context, chatID int64, name string) error
+
+	// Schedule wizard
+	GetWizard(ctx context.Context, chatID int64) (domain.Wizard, error)
+	SaveWizard(ctx context.Context, w domain.Wizard) error
+	DeleteWizard(ctx context.Context, chatID int64) error
+
+	// Message templates
+	SetParseMode(ctx context.Context, chatID int64, parseMode string) error
+	SetTemplate(ctx context.Context, chatID int64, name, body string) error
+	ResetTemplate(ctx context.Context, chatID int64, name string) error
+	ListTemplates(ctx context.Context, subscriptionID string) (map[string]string, error)
 
 	Close()
 }
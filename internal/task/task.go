@@ -13,13 +13,28 @@ type Input struct {
 	Scheduler    domain.Scheduler
 	Subscription domain.Subscription
 	Target       domain.SchedulerTarget
+	// Location is the schedule target location, nil for subscription coordinates.
+	Location     *domain.Location
 	ScheduledFor time.Time
 }
 
+// Message is one user-facing message produced by a task.
+type Message struct {
+	Text string
+	// ParseMode is a transport parse mode (see transport.ParseModeHTML); empty means plain text.
+	ParseMode string
+	// Severity decides whether the message bypasses quiet hours.
+	Severity domain.Severity
+}
+
 // Result is returned by a task and then delivered to the endpoint.
 type Result struct {
-	Messages []string
+	Messages []Message
 	Payload  string
+	// Commit records the state behind Messages (e.g. alert dedup). The runtime calls it once
+	// every message was handed off, so messages lost before that are produced again next run.
+	// It may be nil.
+	Commit func(ctx context.Context) error
 }
 
 // Runner executes one scheduled task.
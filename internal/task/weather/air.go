@@ -0,0 +1,183 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/render"
+	"cron-weather/internal/storage"
+	"cron-weather/internal/task"
+)
+
+const (
+	// defaultAirLevel is the AQI ("poor") at which air alerts start.
+	defaultAirLevel = 4
+	maxAirLevel     = 5
+
+	// airActiveFingerprint marks that an air alert was delivered and not cleared yet.
+	airActiveFingerprint = "air:active"
+)
+
+// AirTask checks OpenWeather air pollution data and alerts when the AQI crosses a level.
+// It shares the daily quota and alert dedup with the weather task. The air pollution API
+// is free and not billed like One Call, so the account budget is not charged.
+type AirTask struct {
+	log        *slog.Logger
+	repo       storage.Repo
+	client     *Client
+	dailyLimit int
+	level      int
+
+	defaultLanguage string
+}
+
+// AirOptions configures the air quality task.
+type AirOptions struct {
+	// DailyLimit is the per-subscription API request cap.
+	DailyLimit int
+	// Level is the AQI (1..5) that triggers an alert (default 4, "poor").
+	Level int
+	// DefaultLanguage is used for subscriptions without a language set.
+	DefaultLanguage string
+}
+
+// NewAirTask constructs an air quality task runner.
+func NewAirTask(log *slog.Logger, repo storage.Repo, client *Client, opts AirOptions) *AirTask {
+	if log == nil {
+		log = slog.Default()
+	}
+	if opts.Level <= 0 || opts.Level > maxAirLevel {
+		opts.Level = defaultAirLevel
+	}
+	return &AirTask{
+		log:        log,
+		repo:       repo,
+		client:     client,
+		dailyLimit: opts.DailyLimit,
+		level:      opts.Level,
+
+		defaultLanguage: opts.DefaultLanguage,
+	}
+}
+
+// Run executes one air quality check and returns user-facing messages.
+func (t *AirTask) Run(ctx context.Context, in task.Input) (task.Result, error) {
+	p, ok := target(in)
+	if !ok {
+		return task.Result{}, fmt.Errorf("subscription has no location; set it via /set_location <lat> <lon>")
+	}
+
+	if t.repo != nil {
+		ok, used, err := t.repo.ReserveDailyUsage(ctx, in.Subscription.ID, time.Now(), t.dailyLimit)
+		if err != nil {
+			return task.Result{}, err
+		}
+		if !ok {
+			return task.Result{}, fmt.Errorf("daily limit exceeded (%d/%d)", used, t.dailyLimit)
+		}
+	}
+	aq, err := t.client.AirPollution(ctx, roundCoord(p.Lat), roundCoord(p.Lon))
+	if err != nil {
+		return task.Result{}, err
+	}
+
+	r := newRenderer(ctx, t.log, t.repo, in, language(in, t.defaultLanguage))
+	var state pending
+	msgs, notified, err := t.messages(ctx, in, p, r, aq, &state)
+	if err != nil {
+		return task.Result{}, err
+	}
+	if aq.AQI >= t.level {
+		t.log.Warn("openweather air quality alert",
+			slog.Int("aqi", aq.AQI),
+			slog.Bool("notified", notified),
+			slog.String("subscription_id", in.Subscription.ID),
+			slog.String("location", p.Name),
+		)
+	}
+
+	b, _ := json.Marshal(map[string]any{
+		"aqi":      aq.AQI,
+		"pm2_5":    aq.PM25,
+		"pm10":     aq.PM10,
+		"o3":       aq.O3,
+		"no2":      aq.NO2,
+		"notified": notified,
+	})
+
+	for i := range msgs {
+		msgs[i].ParseMode = r.opts.ParseMode
+	}
+	return task.Result{Messages: msgs, Payload: string(b), Commit: state.commit()}, nil
+}
+
+// messages decides whether the AQI produces a notice.
+// Each index from the level up to the current AQI is marked as delivered, so only a
+// worsening index alerts again; when the AQI drops below the level a clear message is sent.
+// State changes are added to state and applied once the messages are handed off.
+func (t *AirTask) messages(ctx context.Context, in task.Input, p place, r renderer, aq AirQuality, state *pending) ([]task.Message, bool, error) {
+	v := airView{Location: p.Name, AQI: aq.AQI, PM25: aq.PM25, PM10: aq.PM10, O3: aq.O3, NO2: aq.NO2}
+
+	if aq.AQI < t.level {
+		if t.repo == nil {
+			return nil, false, nil
+		}
+		active, err := t.repo.HasAlertSent(ctx, in.Subscription.ID, p.Key, airActiveFingerprint)
+		if err != nil || !active {
+			return nil, false, err
+		}
+		state.add(func(ctx context.Context) error {
+			if _, err := t.repo.ClearAlertSent(ctx, in.Subscription.ID, p.Key, airActiveFingerprint); err != nil {
+				return err
+			}
+			for k := t.level; k <= maxAirLevel; k++ {
+				if _, err := t.repo.ClearAlertSent(ctx, in.Subscription.ID, p.Key, airFingerprint(k)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		m, err := r.render(render.AirClear, v)
+		if err != nil {
+			return nil, false, err
+		}
+		return []task.Message{{Text: m, Severity: domain.SeverityInfo}}, false, nil
+	}
+
+	if t.repo != nil {
+		// Lower indexes are marked together with the current one, so it alone tells a repeat.
+		sent, err := t.repo.HasAlertSent(ctx, in.Subscription.ID, p.Key, airFingerprint(aq.AQI))
+		if err != nil || sent {
+			return nil, false, err
+		}
+		state.add(func(ctx context.Context) error {
+			if _, err := t.repo.MarkAlertSent(ctx, in.Subscription.ID, p.Key, airActiveFingerprint); err != nil {
+				return err
+			}
+			for k := t.level; k <= aq.AQI; k++ {
+				if _, err := t.repo.MarkAlertSent(ctx, in.Subscription.ID, p.Key, airFingerprint(k)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	m, err := r.render(render.Air, v)
+	if err != nil {
+		return nil, false, err
+	}
+	severity := domain.SeverityModerate
+	if aq.AQI >= maxAirLevel {
+		severity = domain.SeveritySevere
+	}
+	return []task.Message{{Text: m, Severity: severity}}, true, nil
+}
+
+func airFingerprint(aqi int) string {
+	return fmt.Sprintf("air:aqi:%d", aqi)
+}
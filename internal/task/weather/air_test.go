@@ -0,0 +1,62 @@
+package weather
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newAirServer serves the air pollution API with the AQI stored in aqi.
+func newAirServer(t *testing.T, aqi *atomic.Int32) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"list":[{"main":{"aqi":%d},"components":{"pm2_5":12.5,"pm10":20}}]}`, aqi.Load())
+	}))
+	t.Cleanup(srv.Close)
+	return NewOpenWeatherClient("key", WithBaseURL(srv.URL), WithRetry(1, 0, 0))
+}
+
+func TestAirAlertLadder(t *testing.T) {
+	var aqi atomic.Int32
+	at := NewAirTask(nil, newMemRepo(), newAirServer(t, &aqi), AirOptions{Level: 3})
+
+	steps := []struct {
+		name string
+		aqi  int32
+		want string // substring of the only message, "" for none
+		lost bool   // the hand-off failed, so the run is not committed
+	}{
+		{name: "below level", aqi: 2},
+		{name: "crosses level, hand-off fails", aqi: 3, want: "3", lost: true},
+		{name: "crosses level", aqi: 3, want: "3"},
+		{name: "same level", aqi: 3},
+		{name: "worsens", aqi: 5, want: "5"},
+		{name: "improves above level", aqi: 4},
+		{name: "worsens to a delivered level", aqi: 5},
+		{name: "clears below level, hand-off fails", aqi: 1, want: "back to normal", lost: true},
+		{name: "clears below level", aqi: 1, want: "back to normal"},
+		{name: "stays clear", aqi: 2},
+		{name: "re-alerts after clear", aqi: 4, want: "4"},
+	}
+	for _, st := range steps {
+		aqi.Store(st.aqi)
+		res, err := at.Run(t.Context(), testInput())
+		if err != nil {
+			t.Fatalf("%s: Run: %v", st.name, err)
+		}
+		switch {
+		case st.want == "" && len(res.Messages) != 0:
+			t.Errorf("%s: messages = %+v, want none", st.name, res.Messages)
+		case st.want != "" && (len(res.Messages) != 1 || !strings.Contains(res.Messages[0].Text, st.want)):
+			t.Errorf("%s: messages = %+v, want one containing %q", st.name, res.Messages, st.want)
+		}
+		if res.Commit != nil && !st.lost {
+			if err := res.Commit(t.Context()); err != nil {
+				t.Fatalf("%s: Commit: %v", st.name, err)
+			}
+		}
+	}
+}
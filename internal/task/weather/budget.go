@@ -0,0 +1,169 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/i18n"
+	"cron-weather/internal/storage"
+)
+
+// Budget thresholds in percent of a limit.
+const (
+	budgetWarnPct      = 80
+	budgetExhaustedPct = 100
+)
+
+// ErrBudgetExhausted is returned when the account budget has no calls left.
+var ErrBudgetExhausted = errors.New("openweather budget exhausted")
+
+// BudgetOptions configures the account-wide API budget.
+type BudgetOptions struct {
+	// Daily and Monthly cap API calls for the whole account (0 means unlimited).
+	Daily   int
+	Monthly int
+	// DegradeInterval is how old a cached response of a quiet place may be
+	// once usage reaches the warning threshold.
+	DegradeInterval time.Duration
+	// Language of admin notifications.
+	Language string
+	// Notify delivers admin notifications; it may be nil.
+	Notify func(ctx context.Context, text string)
+}
+
+// Budget enforces the account-wide OpenWeather budget and notifies the admin
+// when usage reaches 80% and 100% of the daily or monthly limit.
+//
+// From 80% the budget is degraded. That only means the weather task reuses cached responses of
+// quiet places (no alerts or urgent codes) up to DegradeInterval old instead of calling the API.
+// Schedules keep their frequency, and subscribers are not told; the state shows in the admin
+// /usage and notifications.
+type Budget struct {
+	log  *slog.Logger
+	repo storage.Repo
+	opts BudgetOptions
+}
+
+// NewBudget constructs a budget backed by repo.
+func NewBudget(log *slog.Logger, repo storage.Repo, opts BudgetOptions) *Budget {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &Budget{log: log, repo: repo, opts: opts}
+}
+
+// Reserve reserves one API call or returns an error when the budget is exhausted.
+func (b *Budget) Reserve(ctx context.Context) error {
+	if b == nil || b.repo == nil {
+		return nil
+	}
+
+	u, ok, err := b.repo.ReserveBudget(ctx, time.Now(), b.opts.Daily, b.opts.Monthly)
+	if err != nil {
+		return err
+	}
+
+	b.notify(ctx, u.Day, "budget.day", u.DayUsed, u.DayLimit)
+	b.notify(ctx, u.Month, "budget.month", u.MonthUsed, u.MonthLimit)
+
+	if !ok {
+		return fmt.Errorf("%w (day %d/%d, month %d/%d)", ErrBudgetExhausted, u.DayUsed, u.DayLimit, u.MonthUsed, u.MonthLimit)
+	}
+	return nil
+}
+
+// Wrap returns p with every Forecast call reserved from the budget.
+func (b *Budget) Wrap(p Provider) Provider {
+	if b == nil {
+		return p
+	}
+	return budgetedProvider{Provider: p, budget: b}
+}
+
+type budgetedProvider struct {
+	Provider
+	budget *Budget
+}
+
+func (p budgetedProvider) Forecast(ctx context.Context, req Request) (Forecast, error) {
+	if err := p.budget.Reserve(ctx); err != nil {
+		return Forecast{}, err
+	}
+	return p.Provider.Forecast(ctx, req)
+}
+
+// Degraded reports whether the stored usage reached the warning threshold.
+// It reads api_budget, so it holds across restarts and replicas.
+func (b *Budget) Degraded(ctx context.Context) bool {
+	if b == nil || b.repo == nil {
+		return false
+	}
+	u, err := b.Usage(ctx)
+	if err != nil {
+		b.log.Warn("failed to load budget usage", slog.Any("err", err))
+		return false
+	}
+	return u.DayPercent() >= budgetWarnPct || u.MonthPercent() >= budgetWarnPct
+}
+
+// DegradeInterval returns the maximum age of reused responses while degraded.
+func (b *Budget) DegradeInterval() time.Duration {
+	if b == nil {
+		return 0
+	}
+	return b.opts.DegradeInterval
+}
+
+// Usage returns the current account-wide usage with configured limits.
+func (b *Budget) Usage(ctx context.Context) (domain.BudgetUsage, error) {
+	if b == nil || b.repo == nil {
+		return domain.BudgetUsage{}, fmt.Errorf("budget is not configured")
+	}
+	u, err := b.repo.GetBudgetUsage(ctx, time.Now())
+	if err != nil {
+		return u, err
+	}
+	u.DayLimit, u.MonthLimit = b.opts.Daily, b.opts.Monthly
+	return u, nil
+}
+
+// notify sends one admin notification per period and threshold.
+func (b *Budget) notify(ctx context.Context, period, labelKey string, used, limit int) {
+	if limit <= 0 {
+		return
+	}
+	pct := used * 100 / limit
+	for _, th := range []int{budgetExhaustedPct, budgetWarnPct} {
+		if pct < th {
+			continue
+		}
+		marked, err := b.repo.MarkBudgetNotified(ctx, period, th)
+		if err != nil {
+			b.log.Error("failed to mark budget notification", slog.Any("err", err), slog.String("period", period))
+			return
+		}
+		if !marked {
+			return
+		}
+
+		key := "budget.threshold"
+		if th == budgetExhaustedPct {
+			key = "budget.exhausted"
+		}
+		label := i18n.T(b.opts.Language, labelKey)
+		b.log.Warn("openweather budget threshold reached",
+			slog.String("period", period),
+			slog.Int("threshold", th),
+			slog.Int("used", used),
+			slog.Int("limit", limit),
+		)
+		if b.opts.Notify != nil {
+			b.opts.Notify(ctx, i18n.T(b.opts.Language, key, label, th, used, limit))
+		}
+		return
+	}
+}
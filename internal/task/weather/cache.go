@@ -0,0 +1,165 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"cron-weather/internal/storage"
+)
+
+const (
+	// cachePrecision is the number of decimal places coordinates are rounded to (~1 km).
+	cachePrecision = 2
+	// cacheRetention keeps expired entries in memory for Lookup with a larger max age.
+	cacheRetention = 24 * time.Hour
+)
+
+// CacheStore keeps cached responses shared across replicas (implemented by storage.Repo).
+type CacheStore interface {
+	GetWeatherCache(ctx context.Context, key string) ([]byte, time.Time, error)
+	PutWeatherCache(ctx context.Context, key string, body []byte, fetchedAt time.Time) error
+}
+
+// Cache is a TTL cache of encoded forecasts shared by all subscriptions.
+// Entries live in memory and, when a store is set, in Postgres.
+// Concurrent misses for the same key are coalesced into one upstream call.
+type Cache struct {
+	log   *slog.Logger
+	ttl   time.Duration
+	store CacheStore
+
+	mu    sync.Mutex
+	items map[string]cacheEntry
+
+	group singleflight.Group
+}
+
+type cacheEntry struct {
+	body      []byte
+	fetchedAt time.Time
+}
+
+// NewCache constructs a cache. store may be nil for a process-local cache.
+func NewCache(log *slog.Logger, ttl time.Duration, store CacheStore) *Cache {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &Cache{
+		log:   log,
+		ttl:   ttl,
+		store: store,
+		items: map[string]cacheEntry{},
+	}
+}
+
+// roundCoord rounds a coordinate to cachePrecision decimal places.
+func roundCoord(v float64) float64 {
+	p := math.Pow10(cachePrecision)
+	return math.Round(v*p) / p
+}
+
+// cacheKey identifies a provider request; lat and lon must already be rounded.
+func cacheKey(provider string, lat, lon float64, lang, units string) string {
+	return fmt.Sprintf("%s:%.*f:%.*f:%s:%s", provider, cachePrecision, lat, cachePrecision, lon, lang, units)
+}
+
+// Fetch returns the cached body for key if it is younger than the TTL.
+// Otherwise it calls fetch once per key across concurrent callers and caches the result.
+// The shared call gets a context detached from the caller that started it, so one
+// cancelled caller does not fail the others; each caller stops waiting when its ctx is done.
+func (c *Cache) Fetch(ctx context.Context, key string, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if body, ok := c.get(ctx, key, c.ttl); ok {
+		return body, nil
+	}
+
+	shared := context.WithoutCancel(ctx)
+	ch := c.group.DoChan(key, func() (any, error) {
+		// Another caller may have filled the cache while we waited.
+		if body, ok := c.get(shared, key, c.ttl); ok {
+			return body, nil
+		}
+		body, err := fetch(shared)
+		if err != nil {
+			return nil, err
+		}
+		c.put(shared, key, body, time.Now())
+		return body, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	}
+}
+
+// Fresh returns the cached body for key if it is younger than the TTL, without fetching.
+func (c *Cache) Fresh(ctx context.Context, key string) ([]byte, bool) {
+	return c.get(ctx, key, c.ttl)
+}
+
+// Lookup returns the cached body for key if it is younger than maxAge, without fetching.
+func (c *Cache) Lookup(ctx context.Context, key string, maxAge time.Duration) ([]byte, bool) {
+	return c.get(ctx, key, maxAge)
+}
+
+func (c *Cache) get(ctx context.Context, key string, maxAge time.Duration) ([]byte, bool) {
+	if maxAge <= 0 {
+		return nil, false
+	}
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.items[key]
+	c.mu.Unlock()
+	if ok && now.Sub(e.fetchedAt) < maxAge {
+		return e.body, true
+	}
+
+	if c.store == nil {
+		return nil, false
+	}
+	body, fetchedAt, err := c.store.GetWeatherCache(ctx, key)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			c.log.Warn("weather cache read failed", slog.Any("err", err), slog.String("key", key))
+		}
+		return nil, false
+	}
+	if now.Sub(fetchedAt) >= maxAge {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	c.items[key] = cacheEntry{body: body, fetchedAt: fetchedAt}
+	c.mu.Unlock()
+	return body, true
+}
+
+func (c *Cache) put(ctx context.Context, key string, body []byte, fetchedAt time.Time) {
+	c.mu.Lock()
+	for k, e := range c.items {
+		if fetchedAt.Sub(e.fetchedAt) >= max(c.ttl, cacheRetention) {
+			delete(c.items, k)
+		}
+	}
+	c.items[key] = cacheEntry{body: body, fetchedAt: fetchedAt}
+	c.mu.Unlock()
+
+	if c.store == nil {
+		return
+	}
+	if err := c.store.PutWeatherCache(ctx, key, body, fetchedAt); err != nil {
+		c.log.Warn("weather cache write failed", slog.Any("err", err), slog.String("key", key))
+	}
+}
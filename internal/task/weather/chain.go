@@ -0,0 +1,196 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatusError is a non-200 provider response.
+type StatusError struct {
+	Provider string
+	Status   int
+	// Detail is the decoded provider error message, if any.
+	Detail string
+}
+
+func (e *StatusError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("%s error: http=%d", e.Provider, e.Status)
+	}
+	return fmt.Sprintf("%s error: http=%d %s", e.Provider, e.Status, e.Detail)
+}
+
+// ChainOptions configures provider failover.
+type ChainOptions struct {
+	// FailureThreshold is the number of consecutive failures that opens a provider breaker (default 3).
+	FailureThreshold int
+	// ProbeInterval is how often providers with an open breaker are probed (default 5m).
+	ProbeInterval time.Duration
+}
+
+// Chain is a Provider that tries providers in order and falls back on failure.
+// Each provider has a circuit breaker: after FailureThreshold consecutive failures
+// (network errors, 401/403, 429, 5xx) it is skipped until a background probe succeeds.
+type Chain struct {
+	log     *slog.Logger
+	members []*chainMember
+	opts    ChainOptions
+
+	mu      sync.Mutex
+	lastReq *Request
+}
+
+type chainMember struct {
+	provider Provider
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+}
+
+// NewChain constructs a failover chain; providers are tried in the given order.
+func NewChain(log *slog.Logger, providers []Provider, opts ChainOptions) *Chain {
+	if log == nil {
+		log = slog.Default()
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 3
+	}
+	if opts.ProbeInterval <= 0 {
+		opts.ProbeInterval = 5 * time.Minute
+	}
+	c := &Chain{log: log, opts: opts}
+	for _, p := range providers {
+		c.members = append(c.members, &chainMember{provider: p})
+	}
+	return c
+}
+
+// Name implements Provider.
+func (c *Chain) Name() string {
+	names := make([]string, 0, len(c.members))
+	for _, m := range c.members {
+		names = append(names, m.provider.Name())
+	}
+	return "chain(" + strings.Join(names, ",") + ")"
+}
+
+// Forecast implements Provider. Forecast.Source names the provider that answered.
+func (c *Chain) Forecast(ctx context.Context, req Request) (Forecast, error) {
+	c.mu.Lock()
+	c.lastReq = &req
+	c.mu.Unlock()
+
+	var errs []error
+	for i, m := range c.members {
+		name := m.provider.Name()
+		if m.isOpen() {
+			errs = append(errs, fmt.Errorf("%s: circuit open", name))
+			continue
+		}
+
+		f, err := m.provider.Forecast(ctx, req)
+		if err == nil {
+			m.success()
+			if f.Source == "" {
+				f.Source = name
+			}
+			if i > 0 {
+				c.log.Warn("weather provider fallback used", slog.String("provider", name), slog.Any("errors", errs))
+			}
+			return f, nil
+		}
+		if ctx.Err() != nil {
+			return Forecast{}, err
+		}
+		if tripsBreaker(err) && m.failure(c.opts.FailureThreshold) {
+			c.log.Warn("weather provider circuit opened", slog.String("provider", name), slog.Any("err", err))
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	return Forecast{}, fmt.Errorf("all weather providers failed: %w", errors.Join(errs...))
+}
+
+// Probe periodically retries providers with an open breaker using the last request
+// and closes the breaker on success. It blocks until ctx is cancelled.
+func (c *Chain) Probe(ctx context.Context) {
+	t := time.NewTicker(c.opts.ProbeInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		c.mu.Lock()
+		req := c.lastReq
+		c.mu.Unlock()
+		if req == nil {
+			continue
+		}
+
+		for _, m := range c.members {
+			if !m.isOpen() {
+				continue
+			}
+			name := m.provider.Name()
+			if _, err := m.provider.Forecast(ctx, *req); err != nil {
+				c.log.Debug("weather provider probe failed", slog.String("provider", name), slog.Any("err", err))
+				continue
+			}
+			m.success()
+			c.log.Info("weather provider circuit closed", slog.String("provider", name))
+		}
+	}
+}
+
+// tripsBreaker reports whether err indicates an unhealthy provider
+// rather than a bad request or a local condition.
+func tripsBreaker(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrBudgetExhausted) {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		switch {
+		case se.Status == http.StatusUnauthorized, se.Status == http.StatusForbidden,
+			se.Status == http.StatusTooManyRequests, se.Status >= 500:
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (m *chainMember) isOpen() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.open
+}
+
+func (m *chainMember) success() {
+	m.mu.Lock()
+	m.failures = 0
+	m.open = false
+	m.mu.Unlock()
+}
+
+// failure records a failure and reports whether it opened the breaker.
+func (m *chainMember) failure(threshold int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures++
+	if !m.open && m.failures >= threshold {
+		m.open = true
+		return true
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) Forecast(context.Context, Request) (Forecast, error) {
+	p.calls++
+	if p.err != nil {
+		return Forecast{}, p.err
+	}
+	return Forecast{Temp: 1}, nil
+}
+
+func TestChainFailover(t *testing.T) {
+	primary := &fakeProvider{name: "primary", err: &StatusError{Provider: "primary", Status: http.StatusTooManyRequests}}
+	secondary := &fakeProvider{name: "secondary"}
+	c := NewChain(nil, []Provider{primary, secondary}, ChainOptions{FailureThreshold: 2, ProbeInterval: 10 * time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		f, err := c.Forecast(context.Background(), Request{})
+		if err != nil {
+			t.Fatalf("Forecast: %v", err)
+		}
+		if f.Source != "secondary" {
+			t.Errorf("source = %q, want secondary", f.Source)
+		}
+	}
+	// The breaker opens after two failures, so the third run skips the primary.
+	if primary.calls != 2 {
+		t.Errorf("primary calls = %d, want 2", primary.calls)
+	}
+
+	// A successful probe closes the breaker.
+	primary.err = nil
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go c.Probe(ctx)
+	for c.members[0].isOpen() {
+		if ctx.Err() != nil {
+			t.Fatal("probe did not close the breaker")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	f, err := c.Forecast(context.Background(), Request{})
+	if err != nil {
+		t.Fatalf("Forecast: %v", err)
+	}
+	if f.Source != "primary" {
+		t.Errorf("source = %q, want primary", f.Source)
+	}
+}
+
+func TestChainBadRequestDoesNotTrip(t *testing.T) {
+	primary := &fakeProvider{name: "primary", err: &StatusError{Provider: "primary", Status: http.StatusBadRequest}}
+	secondary := &fakeProvider{name: "secondary", err: errors.New("dial tcp: connection refused")}
+	c := NewChain(nil, []Provider{primary, secondary}, ChainOptions{FailureThreshold: 1})
+
+	if _, err := c.Forecast(context.Background(), Request{}); err == nil {
+		t.Fatal("expected error when all providers fail")
+	}
+	if c.members[0].isOpen() {
+		t.Error("400 must not open the breaker")
+	}
+	if !c.members[1].isOpen() {
+		t.Error("network error must open the breaker")
+	}
+}
+
+// switchProvider fails while err is set and returns f otherwise.
+type switchProvider struct {
+	name string
+	f    Forecast
+	err  error
+}
+
+func (p *switchProvider) Name() string { return p.name }
+
+func (p *switchProvider) Forecast(context.Context, Request) (Forecast, error) {
+	if p.err != nil {
+		return Forecast{}, p.err
+	}
+	return p.f, nil
+}
+
+func TestChainFallbackKeepsAlerts(t *testing.T) {
+	primary := &switchProvider{name: "openweather", f: Forecast{Alerts: []Alert{testAlert}, AlertsSupported: true, WeatherID: []int{781}}}
+	// The fallback knows no alerts and no urgent codes.
+	secondary := &switchProvider{name: "openmeteo", f: Forecast{WeatherID: []int{800}}}
+	c := NewChain(nil, []Provider{primary, secondary}, ChainOptions{FailureThreshold: 1})
+	wt := NewTask(nil, newMemRepo(), c, Options{UrgentAllClear: true})
+
+	if got := run(t, wt, true); len(got) != 2 {
+		t.Fatalf("primary: messages = %q, want alert and urgent notice", got)
+	}
+
+	primary.err = &StatusError{Provider: "openweather", Status: http.StatusServiceUnavailable}
+	for i := 0; i < 2; i++ {
+		if got := run(t, wt, true); len(got) != 0 {
+			t.Fatalf("fallback run %d: messages = %q, want none", i, got)
+		}
+	}
+
+	// Recovery: the alert and the urgent condition are still known, nothing is repeated.
+	primary.err = nil
+	c.members[0].success()
+	if got := run(t, wt, true); len(got) != 0 {
+		t.Fatalf("recovered: messages = %q, want none", got)
+	}
+
+	// Once the primary reports the alert gone, it ends as usual.
+	primary.f = Forecast{AlertsSupported: true, WeatherID: []int{800}}
+	if got := run(t, wt, true); len(got) != 2 {
+		t.Fatalf("ended: messages = %q, want ended alert and all clear", got)
+	}
+}
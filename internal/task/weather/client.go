@@ -1,4 +1,4 @@
-// Package weather implements the OpenWeather task and client.
+// Package weather implements the weather task and its data providers.
 package weather
 
 import (
@@ -10,9 +10,12 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	neturl "net/url"
 	"strconv"
 	"strings"
 	"time"
+
+	"cron-weather/internal/domain"
 )
 
 // Client is a minimal OpenWeather One Call 3.0 client.
@@ -20,24 +23,97 @@ import (
 // Business rules (dedup, urgent codes, messaging) live in the Task.
 type Client struct {
 	apiKey string
-	http   *http.Client
+
+	httpBase
+}
+
+// httpBase performs HTTP GET requests with the retry policy shared by all providers.
+type httpBase struct {
+	baseURL string
+	http    *http.Client
+	// timeout bounds a single attempt, including reading the body.
+	timeout   time.Duration
+	bodyLimit int64
 
 	maxAttempts int
 	baseBackoff time.Duration
 	maxBackoff  time.Duration
 }
 
-// NewOpenWeatherClient constructs an OpenWeather One Call 3.0 API client.
-func NewOpenWeatherClient(apiKey string) *Client {
-	return &Client{
-		apiKey:      apiKey,
-		http:        &http.Client{Timeout: 12 * time.Second},
+func defaultHTTPBase(baseURL string) httpBase {
+	return httpBase{
+		baseURL:     baseURL,
+		http:        &http.Client{},
+		timeout:     12 * time.Second,
+		bodyLimit:   2 << 20,
 		maxAttempts: 4,
 		baseBackoff: 600 * time.Millisecond,
 		maxBackoff:  10 * time.Second,
 	}
 }
 
+// Option configures an HTTP weather client.
+type Option func(*httpBase)
+
+// WithBaseURL points the client at another API root (a mock or a proxy).
+func WithBaseURL(u string) Option {
+	return func(b *httpBase) {
+		if u != "" {
+			b.baseURL = strings.TrimRight(u, "/")
+		}
+	}
+}
+
+// WithHTTPClient sets the underlying HTTP client.
+func WithHTTPClient(c *http.Client) Option {
+	return func(b *httpBase) {
+		if c != nil {
+			b.http = c
+		}
+	}
+}
+
+// WithTimeout bounds each request attempt (0 disables the per-attempt timeout).
+func WithTimeout(d time.Duration) Option {
+	return func(b *httpBase) { b.timeout = d }
+}
+
+// WithRetry sets the number of attempts and the exponential backoff bounds.
+func WithRetry(maxAttempts int, baseBackoff, maxBackoff time.Duration) Option {
+	return func(b *httpBase) {
+		if maxAttempts > 0 {
+			b.maxAttempts = maxAttempts
+		}
+		if baseBackoff > 0 {
+			b.baseBackoff = baseBackoff
+		}
+		if maxBackoff > 0 {
+			b.maxBackoff = maxBackoff
+		}
+	}
+}
+
+// WithBodyLimit caps the response body size in bytes.
+func WithBodyLimit(n int64) Option {
+	return func(b *httpBase) {
+		if n > 0 {
+			b.bodyLimit = n
+		}
+	}
+}
+
+// NewOpenWeatherClient constructs an OpenWeather One Call 3.0 API client.
+func NewOpenWeatherClient(apiKey string, opts ...Option) *Client {
+	c := &Client{
+		apiKey:   apiKey,
+		httpBase: defaultHTTPBase("https://api.openweathermap.org"),
+	}
+	for _, opt := range opts {
+		opt(&c.httpBase)
+	}
+	return c
+}
+
 // Alert is an OpenWeather weather alert.
 type Alert struct {
 	SenderName  string   `json:"sender_name"`
@@ -58,8 +134,12 @@ type weatherItem struct {
 type oneCallResponse struct {
 	Alerts  []Alert `json:"alerts"`
 	Current struct {
-		Weather []weatherItem `json:"weather"`
+		Temp      float64       `json:"temp"`
+		FeelsLike float64       `json:"feels_like"`
+		WindSpeed float64       `json:"wind_speed"`
+		Weather   []weatherItem `json:"weather"`
 	} `json:"current"`
+	Minutely []Precipitation `json:"minutely"`
 }
 
 type apiErrorResponse struct {
@@ -82,47 +162,256 @@ func (e apiErrorResponse) codeInt() int {
 	}
 }
 
-// OneCall is a minimal decoded payload for task layer.
-type OneCall struct {
-	Alerts    []Alert
-	WeatherID []int
-}
-
 // OneCall executes OpenWeather One Call 3.0 request and returns decoded response and raw details.
-func (c *Client) OneCall(ctx context.Context, lat, lon float64) (OneCall, int, http.Header, []byte, error) {
+// lang is an OpenWeather language code used for alert and weather descriptions,
+// units is one of domain.UnitsMetric, domain.UnitsImperial or domain.UnitsStandard.
+func (c *Client) OneCall(ctx context.Context, lat, lon float64, lang, units string) (Forecast, int, http.Header, []byte, error) {
+	if lang == "" {
+		lang = "en"
+	}
+	if units == "" {
+		units = domain.UnitsMetric
+	}
 	url := fmt.Sprintf(
-		"https://api.openweathermap.org/data/3.0/onecall?lat=%f&lon=%f&lang=ru&units=metric&appid=%s",
-		lat, lon, c.apiKey,
+		"%s/data/3.0/onecall?lat=%f&lon=%f&lang=%s&units=%s&appid=%s",
+		c.baseURL, lat, lon, neturl.QueryEscape(lang), neturl.QueryEscape(units), c.apiKey,
 	)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return OneCall{}, 0, nil, nil, fmt.Errorf("create request: %w", err)
+		return Forecast{}, 0, nil, nil, c.redact(fmt.Errorf("create request: %w", err))
 	}
 
 	body, status, hdr, err := c.doWithRetry(ctx, req)
 	if err != nil {
-		return OneCall{}, 0, nil, nil, err
+		return Forecast{}, 0, nil, nil, c.redact(err)
 	}
 
 	// Non-200: still return raw body to task for logging.
 	if status != http.StatusOK {
-		return OneCall{}, status, hdr, body, nil
+		return Forecast{}, status, hdr, body, nil
+	}
+
+	out, err := decodeOneCall(body)
+	if err != nil {
+		return Forecast{}, status, hdr, body, err
+	}
+	return out, status, hdr, body, nil
+}
+
+// Name implements Provider.
+func (c *Client) Name() string { return ProviderOpenWeather }
+
+// Forecast implements Provider on top of OneCall; non-200 responses become errors.
+func (c *Client) Forecast(ctx context.Context, req Request) (Forecast, error) {
+	f, status, _, raw, err := c.OneCall(ctx, req.Lat, req.Lon, req.Lang, req.Units)
+	if err != nil {
+		return Forecast{}, err
+	}
+	if status != http.StatusOK {
+		if apiErr, ok := DecodeAPIError(raw); ok {
+			return Forecast{}, c.redact(&StatusError{Provider: c.Name(), Status: status, Detail: fmt.Sprintf("cod=%d message=%q parameters=%v", apiErr.codeInt(), apiErr.Message, apiErr.Parameters)})
+		}
+		preview := string(raw)
+		if len(preview) > 300 {
+			preview = preview[:300] + "..."
+		}
+		return Forecast{}, c.redact(&StatusError{Provider: c.Name(), Status: status, Detail: fmt.Sprintf("body=%q", preview)})
 	}
+	return f, nil
+}
 
+// decodeOneCall decodes a successful One Call response body.
+func decodeOneCall(body []byte) (Forecast, error) {
 	var resp oneCallResponse
 	if err := json.Unmarshal(body, &resp); err != nil {
-		return OneCall{}, status, hdr, body, fmt.Errorf("decode response: %w", err)
+		return Forecast{}, fmt.Errorf("decode response: %w", err)
 	}
 
-	out := OneCall{Alerts: resp.Alerts}
+	out := Forecast{
+		Alerts:          resp.Alerts,
+		AlertsSupported: true,
+		Temp:            resp.Current.Temp,
+		FeelsLike:       resp.Current.FeelsLike,
+		WindSpeed:       resp.Current.WindSpeed,
+		Minutely:        resp.Minutely,
+	}
 	for _, w := range resp.Current.Weather {
 		out.WeatherID = append(out.WeatherID, w.ID)
+		if out.Description == "" {
+			out.Description = w.Description
+		}
 	}
-	return out, status, hdr, body, nil
+	return out, nil
+}
+
+// AirQuality is a decoded air pollution sample.
+type AirQuality struct {
+	// AQI is the OpenWeather air quality index: 1 (good) to 5 (very poor).
+	AQI int
+	// Pollutant concentrations in µg/m³.
+	PM25 float64
+	PM10 float64
+	O3   float64
+	NO2  float64
+}
+
+type airPollutionResponse struct {
+	List []struct {
+		Main struct {
+			AQI int `json:"aqi"`
+		} `json:"main"`
+		Components struct {
+			PM25 float64 `json:"pm2_5"`
+			PM10 float64 `json:"pm10"`
+			O3   float64 `json:"o3"`
+			NO2  float64 `json:"no2"`
+		} `json:"components"`
+	} `json:"list"`
 }
 
-func (c *Client) doWithRetry(ctx context.Context, req *http.Request) ([]byte, int, http.Header, error) {
+// AirPollution returns current air quality from the OpenWeather air pollution API.
+func (c *Client) AirPollution(ctx context.Context, lat, lon float64) (AirQuality, error) {
+	url := fmt.Sprintf(
+		"%s/data/2.5/air_pollution?lat=%f&lon=%f&appid=%s",
+		c.baseURL, lat, lon, c.apiKey,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return AirQuality{}, c.redact(fmt.Errorf("create request: %w", err))
+	}
+
+	body, status, _, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return AirQuality{}, c.redact(err)
+	}
+	if status != http.StatusOK {
+		se := &StatusError{Provider: c.Name(), Status: status}
+		if apiErr, ok := DecodeAPIError(body); ok {
+			se.Detail = fmt.Sprintf("message=%q", apiErr.Message)
+		}
+		return AirQuality{}, c.redact(se)
+	}
+
+	var resp airPollutionResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return AirQuality{}, fmt.Errorf("decode air pollution response: %w", err)
+	}
+	if len(resp.List) == 0 {
+		return AirQuality{}, fmt.Errorf("air pollution response has no data")
+	}
+	it := resp.List[0]
+	return AirQuality{
+		AQI:  it.Main.AQI,
+		PM25: it.Components.PM25,
+		PM10: it.Components.PM10,
+		O3:   it.Components.O3,
+		NO2:  it.Components.NO2,
+	}, nil
+}
+
+// redact hides the API key in err. Transport errors (*url.Error) carry the request URL,
+// which includes the appid query parameter.
+func (c *Client) redact(err error) error {
+	if err == nil || c.apiKey == "" {
+		return err
+	}
+	var ue *neturl.Error
+	if errors.As(err, &ue) {
+		ue.URL = strings.ReplaceAll(ue.URL, c.apiKey, redactedSecret)
+	}
+	if !strings.Contains(err.Error(), c.apiKey) {
+		return err
+	}
+	return &redactedError{msg: strings.ReplaceAll(err.Error(), c.apiKey, redactedSecret), err: err}
+}
+
+const redactedSecret = "***"
+
+// redactedError is an error whose message has secrets removed.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// Place is a geocoding result.
+type Place struct {
+	Name    string
+	State   string
+	Country string
+	Lat     float64
+	Lon     float64
+}
+
+// Label returns a human-readable place name such as "Vilnius, Vilnius County, LT".
+func (p Place) Label() string {
+	parts := []string{p.Name}
+	if p.State != "" && p.State != p.Name {
+		parts = append(parts, p.State)
+	}
+	if p.Country != "" {
+		parts = append(parts, p.Country)
+	}
+	return strings.Join(parts, ", ")
+}
+
+type geocodingItem struct {
+	Name       string            `json:"name"`
+	LocalNames map[string]string `json:"local_names"`
+	Lat        float64           `json:"lat"`
+	Lon        float64           `json:"lon"`
+	Country    string            `json:"country"`
+	State      string            `json:"state"`
+}
+
+// Geocode resolves a place name (e.g. "Vilnius" or "Vilnius,LT") via the OpenWeather geocoding API.
+// Place names are localized to lang when OpenWeather knows a local name.
+func (c *Client) Geocode(ctx context.Context, query string, limit int, lang string) ([]Place, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+	url := fmt.Sprintf(
+		"%s/geo/1.0/direct?q=%s&limit=%d&appid=%s",
+		c.baseURL, neturl.QueryEscape(query), limit, c.apiKey,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, c.redact(fmt.Errorf("create request: %w", err))
+	}
+
+	body, status, _, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		return nil, c.redact(err)
+	}
+	if status != http.StatusOK {
+		if apiErr, ok := DecodeAPIError(body); ok {
+			return nil, fmt.Errorf("openweather geocoding error: http=%d message=%q", status, apiErr.Message)
+		}
+		return nil, fmt.Errorf("openweather geocoding error: http=%d", status)
+	}
+
+	var items []geocodingItem
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, fmt.Errorf("decode geocoding response: %w", err)
+	}
+
+	out := make([]Place, 0, len(items))
+	for _, it := range items {
+		name := it.Name
+		if local := it.LocalNames[lang]; local != "" {
+			name = local
+		}
+		out = append(out, Place{Name: name, State: it.State, Country: it.Country, Lat: it.Lat, Lon: it.Lon})
+	}
+	return out, nil
+}
+
+func (c *httpBase) doWithRetry(ctx context.Context, req *http.Request) ([]byte, int, http.Header, error) {
 	attempts := c.maxAttempts
 	if attempts < 1 {
 		attempts = 1
@@ -134,7 +423,7 @@ func (c *Client) doWithRetry(ctx context.Context, req *http.Request) ([]byte, in
 			return nil, 0, nil, ctx.Err()
 		}
 
-		resp, err := c.http.Do(req)
+		b, status, hdr, err := c.attempt(ctx, req)
 		if err != nil {
 			lastErr = err
 			if i == attempts {
@@ -144,19 +433,6 @@ func (c *Client) doWithRetry(ctx context.Context, req *http.Request) ([]byte, in
 			continue
 		}
 
-		hdr := resp.Header
-		status := resp.StatusCode
-		b, rerr := readAllLimit(resp.Body, 2<<20)
-		_ = resp.Body.Close()
-		if rerr != nil {
-			lastErr = rerr
-			if i == attempts {
-				break
-			}
-			c.sleep(ctx, i, 0)
-			continue
-		}
-
 		// Decide retry.
 		switch {
 		case status == http.StatusOK:
@@ -187,7 +463,28 @@ func (c *Client) doWithRetry(ctx context.Context, req *http.Request) ([]byte, in
 	return nil, 0, nil, errors.New("request failed")
 }
 
-func (c *Client) sleep(ctx context.Context, attempt int, forced time.Duration) {
+// attempt performs a single request and reads the body within the per-attempt timeout.
+func (c *httpBase) attempt(ctx context.Context, req *http.Request) ([]byte, int, http.Header, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	b, err := readAllLimit(resp.Body, c.bodyLimit)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return b, resp.StatusCode, resp.Header, nil
+}
+
+func (c *httpBase) sleep(ctx context.Context, attempt int, forced time.Duration) {
 	d := forced
 	if d <= 0 {
 		d = c.baseBackoff * time.Duration(1<<(attempt-1))
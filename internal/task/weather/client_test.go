@@ -0,0 +1,207 @@
+package weather
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubServer answers with the responses in order, repeating the last one.
+func stubServer(t *testing.T, responses ...func(w http.ResponseWriter)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n > len(responses) {
+			n = len(responses)
+		}
+		responses[n-1](w)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func respond(status int, body string, headers ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for i := 0; i+1 < len(headers); i += 2 {
+			w.Header().Set(headers[i], headers[i+1])
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+// fastRetry keeps backoff short so the suite runs offline in milliseconds.
+func fastRetry(attempts int) Option {
+	return WithRetry(attempts, time.Millisecond, 5*time.Millisecond)
+}
+
+const okBody = `{"current":{"temp":1.5,"weather":[{"id":800,"description":"clear sky"}]}}`
+
+func TestClientRetryAfter(t *testing.T) {
+	srv, calls := stubServer(t,
+		respond(http.StatusTooManyRequests, `{"cod":429,"message":"rate limited"}`, "Retry-After", "1"),
+		respond(http.StatusOK, okBody),
+	)
+	c := NewOpenWeatherClient("k", WithBaseURL(srv.URL), fastRetry(3))
+
+	start := time.Now()
+	f, status, _, _, err := c.OneCall(context.Background(), 1, 2, "en", "metric")
+	if err != nil {
+		t.Fatalf("OneCall: %v", err)
+	}
+	if status != http.StatusOK || f.Temp != 1.5 {
+		t.Errorf("status = %d, temp = %v", status, f.Temp)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("Retry-After not honoured: retried after %v", elapsed)
+	}
+}
+
+func TestClientRetryAfterExhausted(t *testing.T) {
+	srv, calls := stubServer(t, respond(http.StatusTooManyRequests, `{"cod":429,"message":"rate limited"}`))
+	c := NewOpenWeatherClient("k", WithBaseURL(srv.URL), fastRetry(2))
+
+	_, status, _, _, err := c.OneCall(context.Background(), 1, 2, "en", "metric")
+	if err != nil {
+		t.Fatalf("OneCall: %v", err)
+	}
+	if status != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", status)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+func TestClientServerErrorBackoff(t *testing.T) {
+	srv, calls := stubServer(t,
+		respond(http.StatusServiceUnavailable, "unavailable"),
+		respond(http.StatusBadGateway, "bad gateway"),
+		respond(http.StatusOK, okBody),
+	)
+	c := NewOpenWeatherClient("k", WithBaseURL(srv.URL), fastRetry(4))
+
+	_, status, _, _, err := c.OneCall(context.Background(), 1, 2, "en", "metric")
+	if err != nil {
+		t.Fatalf("OneCall: %v", err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("status = %d, want 200", status)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("calls = %d, want 3", got)
+	}
+}
+
+func TestClientNoRetryOnClientError(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		srv, calls := stubServer(t, respond(status, `{"cod":400,"message":"nope"}`))
+		c := NewOpenWeatherClient("k", WithBaseURL(srv.URL), fastRetry(4))
+
+		_, got, _, _, err := c.OneCall(context.Background(), 1, 2, "en", "metric")
+		if err != nil {
+			t.Fatalf("OneCall: %v", err)
+		}
+		if got != status || calls.Load() != 1 {
+			t.Errorf("status %d: got %d after %d calls, want 1 call", status, got, calls.Load())
+		}
+	}
+}
+
+func TestClientBodyLimit(t *testing.T) {
+	srv, calls := stubServer(t, respond(http.StatusOK, strings.Repeat("x", 64)))
+	c := NewOpenWeatherClient("k", WithBaseURL(srv.URL), WithBodyLimit(32), fastRetry(2))
+
+	_, _, _, _, err := c.OneCall(context.Background(), 1, 2, "en", "metric")
+	if err == nil || !strings.Contains(err.Error(), "response too large") {
+		t.Fatalf("err = %v, want response too large", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+func TestReadAllLimit(t *testing.T) {
+	b, err := readAllLimit(bytes.NewReader([]byte("12345")), 5)
+	if err != nil || string(b) != "12345" {
+		t.Errorf("at limit: %q, %v", b, err)
+	}
+
+	b, err = readAllLimit(bytes.NewReader([]byte("123456")), 5)
+	if err == nil {
+		t.Error("over limit: expected error")
+	}
+	if string(b) != "12345" {
+		t.Errorf("over limit: got %q, want truncated body", b)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c := NewOpenWeatherClient("k", WithBaseURL(srv.URL), WithTimeout(20*time.Millisecond), fastRetry(2))
+
+	start := time.Now()
+	_, _, _, _, err := c.OneCall(context.Background(), 1, 2, "en", "metric")
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("timeout not applied: took %v", elapsed)
+	}
+}
+
+type countingTransport struct {
+	n atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.n.Add(1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestClientWithHTTPClient(t *testing.T) {
+	srv, _ := stubServer(t, respond(http.StatusOK, okBody))
+	rt := &countingTransport{}
+	c := NewOpenWeatherClient("k", WithBaseURL(srv.URL+"/"), WithHTTPClient(&http.Client{Transport: rt}))
+
+	if _, _, _, _, err := c.OneCall(context.Background(), 1, 2, "en", "metric"); err != nil {
+		t.Fatalf("OneCall: %v", err)
+	}
+	if rt.n.Load() != 1 {
+		t.Errorf("injected client used %d times, want 1", rt.n.Load())
+	}
+}
+
+func TestClientRedactsKeyInErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.Close() // connection refused: *url.Error carries the request URL
+
+	const key = "secret-api-key-123"
+	c := NewOpenWeatherClient(key, WithBaseURL(srv.URL), fastRetry(1))
+
+	_, _, _, _, err := c.OneCall(context.Background(), 1, 2, "en", "metric")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if strings.Contains(err.Error(), key) {
+		t.Errorf("error leaks the API key: %v", err)
+	}
+	if _, err := c.Geocode(context.Background(), "Vilnius", 1, "en"); err == nil || strings.Contains(err.Error(), key) {
+		t.Errorf("geocode error leaks the API key: %v", err)
+	}
+}
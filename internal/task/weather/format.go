@@ -0,0 +1,116 @@
+package weather
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/i18n"
+)
+
+// alertView is the data passed to alert templates.
+type alertView struct {
+	// Location is the schedule location name ("" for subscription coordinates).
+	Location    string
+	Sender      string
+	Event       string
+	Description string
+	Start       time.Time
+	End         time.Time
+	Tags        []string
+	Severity    domain.Severity
+	// Changes is filled for updated alerts only.
+	Changes []string
+}
+
+// urgentView is the data passed to urgent and all-clear templates.
+// Temperatures and speeds are in the subscription units.
+type urgentView struct {
+	Location    string
+	Codes       []int
+	Description string
+	Temp        float64
+	FeelsLike   float64
+	WindSpeed   float64
+}
+
+// airView is the data passed to air quality templates.
+// Pollutant concentrations are in µg/m³.
+type airView struct {
+	Location string
+	AQI      int
+	PM25     float64
+	PM10     float64
+	O3       float64
+	NO2      float64
+}
+
+// nowcastView is the data passed to the nowcast template.
+// StartsIn and Duration are minutes rounded to 5; StartsIn is 0 when rain starts now.
+type nowcastView struct {
+	Location string
+	StartsIn int
+	Duration int
+	// Open means the rain lasts at least Duration (past the end of the forecast).
+	Open bool
+}
+
+// digestView is the data passed to the digest template.
+type digestView struct {
+	Items []string
+}
+
+func newAlertView(a Alert, location string) alertView {
+	return alertView{
+		Location:    location,
+		Sender:      a.SenderName,
+		Event:       a.Event,
+		Description: strings.TrimSpace(a.Description),
+		Start:       time.Unix(a.Start, 0),
+		End:         time.Unix(a.End, 0),
+		Tags:        a.Tags,
+		Severity:    alertSeverity(a),
+	}
+}
+
+func newUrgentView(oc Forecast, codes []int, location string) urgentView {
+	return urgentView{
+		Location:    location,
+		Codes:       codes,
+		Description: oc.Description,
+		Temp:        oc.Temp,
+		FeelsLike:   oc.FeelsLike,
+		WindSpeed:   oc.WindSpeed,
+	}
+}
+
+func newNowcastView(ep rainEpisode, now time.Time, location string) nowcastView {
+	v := nowcastView{Location: location, Duration: roundMinutes(ep.Duration), Open: ep.Open}
+	if d := ep.Start.Sub(now); d >= 5*time.Minute {
+		v.StartsIn = roundMinutes(d)
+	}
+	return v
+}
+
+// alertChanges describes what changed between two versions of the same alert.
+func alertChanges(prev, cur Alert, loc *time.Location, lang string) []string {
+	var changes []string
+	if prev.End != cur.End {
+		changes = append(changes, i18n.T(lang, "alert.change.end", formatAlertTime(prev.End, loc, lang), formatAlertTime(cur.End, loc, lang)))
+	}
+	if !slices.Equal(prev.Tags, cur.Tags) {
+		changes = append(changes, i18n.T(lang, "alert.change.tags", strings.Join(prev.Tags, ", "), strings.Join(cur.Tags, ", ")))
+	}
+	if strings.TrimSpace(prev.Description) != strings.TrimSpace(cur.Description) {
+		changes = append(changes, i18n.T(lang, "alert.change.description"))
+	}
+	if len(changes) == 0 {
+		changes = append(changes, i18n.T(lang, "alert.change.none"))
+	}
+	return changes
+}
+
+func formatAlertTime(unix int64, loc *time.Location, lang string) string {
+	return time.Unix(unix, 0).In(loc).Format(i18n.TimeLayout(lang))
+}
@@ -0,0 +1,162 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/render"
+	"cron-weather/internal/task"
+)
+
+const (
+	defaultNowcastHorizon = 30 * time.Minute
+	maxNowcastHorizon     = time.Hour
+
+	// nowcastActiveFingerprint marks a rain episode that was already announced.
+	nowcastActiveFingerprint = "nowcast:active"
+)
+
+// NowcastTask announces precipitation starting within a horizon using minutely forecasts.
+// It fetches through the weather task, so the cache, daily quota and budget are shared.
+type NowcastTask struct {
+	weather *Task
+	horizon time.Duration
+}
+
+// NewNowcastTask constructs a nowcast runner on top of a weather task.
+// horizon is how far ahead a rain start is announced (default 30m, at most 1h).
+func NewNowcastTask(weather *Task, horizon time.Duration) *NowcastTask {
+	if horizon <= 0 {
+		horizon = defaultNowcastHorizon
+	}
+	if horizon > maxNowcastHorizon {
+		horizon = maxNowcastHorizon
+	}
+	return &NowcastTask{weather: weather, horizon: horizon}
+}
+
+// rainEpisode is precipitation found in a minutely forecast.
+type rainEpisode struct {
+	Start    time.Time
+	Duration time.Duration
+	// Open means precipitation continues past the end of the forecast.
+	Open bool
+}
+
+// Run executes one nowcast check and returns user-facing messages.
+//
+// A rain episode is announced once. It ends when no precipitation is expected within the
+// horizon, so short breaks in the rain do not produce a second message.
+func (n *NowcastTask) Run(ctx context.Context, in task.Input) (task.Result, error) {
+	t := n.weather
+	p, ok := target(in)
+	if !ok {
+		return task.Result{}, fmt.Errorf("subscription has no location; set it via /set_location <lat> <lon>")
+	}
+
+	oc, err := t.fetch(ctx, in, p)
+	if err != nil {
+		return task.Result{}, err
+	}
+
+	now := in.ScheduledFor
+	if now.IsZero() {
+		now = time.Now()
+	}
+	ep, known := nextRain(oc.Minutely, now)
+	fields := map[string]any{"source": oc.Source}
+	if !known {
+		// No minutely data (e.g. another provider answered or the response is stale).
+		b, _ := json.Marshal(fields)
+		return task.Result{Payload: string(b)}, nil
+	}
+
+	r := t.renderer(ctx, in)
+	rainy := ep != nil && ep.Start.Sub(now) <= n.horizon
+	var msgs []task.Message
+	notified := false
+	// The episode mark is recorded only after the messages were handed off.
+	var state pending
+	switch {
+	case !rainy && t.repo != nil:
+		state.add(func(ctx context.Context) error {
+			_, err := t.repo.ClearAlertSent(ctx, in.Subscription.ID, p.Key, nowcastActiveFingerprint)
+			return err
+		})
+	case rainy:
+		if t.repo != nil {
+			sent, err := t.repo.HasAlertSent(ctx, in.Subscription.ID, p.Key, nowcastActiveFingerprint)
+			if err != nil {
+				return task.Result{}, err
+			}
+			if sent {
+				break
+			}
+			state.add(func(ctx context.Context) error {
+				_, err := t.repo.MarkAlertSent(ctx, in.Subscription.ID, p.Key, nowcastActiveFingerprint)
+				return err
+			})
+		}
+		m, err := r.render(render.Nowcast, newNowcastView(*ep, now, p.Name))
+		if err != nil {
+			return task.Result{}, err
+		}
+		msgs = append(msgs, task.Message{Text: m, ParseMode: r.opts.ParseMode, Severity: domain.SeverityMinor})
+		notified = true
+	}
+
+	if ep != nil {
+		fields["rain_start"] = ep.Start.UTC().Format(time.RFC3339)
+		fields["rain_minutes"] = int(ep.Duration / time.Minute)
+		fields["notified"] = notified
+		t.log.Info("precipitation nowcast",
+			slog.Time("start", ep.Start),
+			slog.Duration("duration", ep.Duration),
+			slog.Bool("notified", notified),
+			slog.String("subscription_id", in.Subscription.ID),
+			slog.String("location", p.Name),
+		)
+	}
+	b, _ := json.Marshal(fields)
+	return task.Result{Messages: msgs, Payload: string(b), Commit: state.commit()}, nil
+}
+
+// nextRain returns the first precipitation episode at or after now.
+// known is false when the forecast has no minutes left after now.
+func nextRain(minutely []Precipitation, now time.Time) (ep *rainEpisode, known bool) {
+	from := now.Truncate(time.Minute).Unix()
+	for _, m := range minutely {
+		if m.Time < from {
+			continue
+		}
+		known = true
+		if ep == nil {
+			if m.Value > 0 {
+				ep = &rainEpisode{Start: time.Unix(m.Time, 0), Duration: time.Minute, Open: true}
+			}
+			continue
+		}
+		if !ep.Open {
+			continue
+		}
+		if m.Value > 0 {
+			ep.Duration += time.Minute
+		} else {
+			ep.Open = false
+		}
+	}
+	return ep, known
+}
+
+// roundMinutes rounds d to 5 minutes, with a 5 minute minimum.
+func roundMinutes(d time.Duration) int {
+	m := int(d.Round(5*time.Minute) / time.Minute)
+	if m < 5 {
+		m = 5
+	}
+	return m
+}
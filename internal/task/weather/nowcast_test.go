@@ -0,0 +1,95 @@
+package weather
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// minutes builds a minutely forecast starting at start with one value per minute.
+func minutes(start time.Time, values ...float64) []Precipitation {
+	out := make([]Precipitation, len(values))
+	for i, v := range values {
+		out[i] = Precipitation{Time: start.Add(time.Duration(i) * time.Minute).Unix(), Value: v}
+	}
+	return out
+}
+
+func TestNextRain(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	ep, known := nextRain(minutes(now, 0, 0, 0.3, 0.5, 0, 0.2), now)
+	if !known || ep == nil {
+		t.Fatalf("ep = %v, known = %v", ep, known)
+	}
+	if !ep.Start.Equal(now.Add(2*time.Minute)) || ep.Duration != 2*time.Minute || ep.Open {
+		t.Errorf("episode = %+v", *ep)
+	}
+
+	ep, _ = nextRain(minutes(now, 0, 0.1, 0.1), now)
+	if ep == nil || !ep.Open || ep.Duration != 2*time.Minute {
+		t.Errorf("open episode = %+v", ep)
+	}
+
+	if ep, known := nextRain(minutes(now, 0, 0, 0), now); ep != nil || !known {
+		t.Errorf("dry: ep = %v, known = %v", ep, known)
+	}
+
+	// Minutes before now are ignored; a stale forecast is unknown.
+	if ep, known := nextRain(minutes(now.Add(-time.Hour), 1, 1), now); ep != nil || known {
+		t.Errorf("stale: ep = %v, known = %v", ep, known)
+	}
+}
+
+func TestNewNowcastView(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	v := newNowcastView(rainEpisode{Start: now.Add(14 * time.Minute), Duration: 31 * time.Minute}, now, "")
+	if v.StartsIn != 15 || v.Duration != 30 {
+		t.Errorf("view = %+v, want starts in 15, lasting 30", v)
+	}
+	v = newNowcastView(rainEpisode{Start: now.Add(time.Minute), Duration: 2 * time.Minute}, now, "")
+	if v.StartsIn != 0 || v.Duration != 5 {
+		t.Errorf("view = %+v, want starts now, lasting 5", v)
+	}
+}
+
+func TestNowcastEpisodeRecordedAfterHandOff(t *testing.T) {
+	in := testInput()
+	rain := minutes(in.ScheduledFor, 0, 0, 0.4, 0.4)
+	p := &stubProvider{name: "stub", f: Forecast{Minutely: rain}}
+	nt := NewNowcastTask(NewTask(nil, newMemRepo(), p, Options{}), 0)
+
+	steps := []struct {
+		name   string
+		dry    bool
+		want   int
+		commit bool
+	}{
+		{name: "rain, hand-off fails", want: 1},
+		{name: "rain announced again", want: 1, commit: true},
+		{name: "same episode", commit: true},
+		{name: "dry, not committed", dry: true},
+		{name: "episode still marked", commit: true},
+		{name: "dry", dry: true, commit: true},
+		{name: "new episode", want: 1, commit: true},
+	}
+	for _, st := range steps {
+		p.f.Minutely = rain
+		if st.dry {
+			p.f.Minutely = minutes(in.ScheduledFor, 0, 0, 0)
+		}
+		res, err := nt.Run(context.Background(), in)
+		if err != nil {
+			t.Fatalf("%s: Run: %v", st.name, err)
+		}
+		if len(res.Messages) != st.want {
+			t.Errorf("%s: %d messages, want %d", st.name, len(res.Messages), st.want)
+		}
+		if st.commit && res.Commit != nil {
+			if err := res.Commit(context.Background()); err != nil {
+				t.Fatalf("%s: Commit: %v", st.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+
+	"cron-weather/internal/domain"
+)
+
+// OpenMeteo is an Open-Meteo forecast API client. It needs no API key.
+// Open-Meteo has no weather alerts, so forecasts only carry current conditions.
+type OpenMeteo struct {
+	httpBase
+}
+
+// NewOpenMeteoClient constructs an Open-Meteo API client.
+func NewOpenMeteoClient(opts ...Option) *OpenMeteo {
+	c := &OpenMeteo{httpBase: defaultHTTPBase("https://api.open-meteo.com")}
+	for _, opt := range opts {
+		opt(&c.httpBase)
+	}
+	return c
+}
+
+type openMeteoResponse struct {
+	Current struct {
+		Temperature         float64 `json:"temperature_2m"`
+		ApparentTemperature float64 `json:"apparent_temperature"`
+		WeatherCode         int     `json:"weather_code"`
+		WindSpeed           float64 `json:"wind_speed_10m"`
+	} `json:"current"`
+}
+
+type openMeteoError struct {
+	Error  bool   `json:"error"`
+	Reason string `json:"reason"`
+}
+
+// Name implements Provider.
+func (c *OpenMeteo) Name() string { return ProviderOpenMeteo }
+
+// Forecast implements Provider. WMO weather codes are mapped to OpenWeather condition codes;
+// descriptions are in English regardless of req.Lang.
+func (c *OpenMeteo) Forecast(ctx context.Context, req Request) (Forecast, error) {
+	tempUnit, windUnit := "celsius", "ms"
+	if req.Units == domain.UnitsImperial {
+		tempUnit, windUnit = "fahrenheit", "mph"
+	}
+
+	q := neturl.Values{}
+	q.Set("latitude", fmt.Sprintf("%f", req.Lat))
+	q.Set("longitude", fmt.Sprintf("%f", req.Lon))
+	q.Set("current", "temperature_2m,apparent_temperature,weather_code,wind_speed_10m")
+	q.Set("temperature_unit", tempUnit)
+	q.Set("wind_speed_unit", windUnit)
+	q.Set("timezone", "UTC")
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/v1/forecast?"+q.Encode(), nil)
+	if err != nil {
+		return Forecast{}, fmt.Errorf("create request: %w", err)
+	}
+
+	body, status, _, err := c.doWithRetry(ctx, httpReq)
+	if err != nil {
+		return Forecast{}, err
+	}
+	if status != http.StatusOK {
+		var e openMeteoError
+		if json.Unmarshal(body, &e) == nil && e.Reason != "" {
+			return Forecast{}, &StatusError{Provider: c.Name(), Status: status, Detail: fmt.Sprintf("reason=%q", e.Reason)}
+		}
+		return Forecast{}, &StatusError{Provider: c.Name(), Status: status}
+	}
+
+	var resp openMeteoResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Forecast{}, fmt.Errorf("decode response: %w", err)
+	}
+
+	// Open-Meteo has no weather alerts, so AlertsSupported stays false.
+	cur := resp.Current
+	out := Forecast{
+		Temp:      cur.Temperature,
+		FeelsLike: cur.ApparentTemperature,
+		WindSpeed: cur.WindSpeed,
+	}
+	if req.Units == domain.UnitsStandard {
+		out.Temp += 273.15
+		out.FeelsLike += 273.15
+	}
+	if cond, ok := wmoConditions[cur.WeatherCode]; ok {
+		out.WeatherID = []int{cond.id}
+		out.Description = cond.description
+	}
+	return out, nil
+}
+
+// wmoConditions maps WMO weather interpretation codes to OpenWeather condition codes.
+var wmoConditions = map[int]struct {
+	id          int
+	description string
+}{
+	0:  {800, "clear sky"},
+	1:  {801, "mainly clear"},
+	2:  {802, "partly cloudy"},
+	3:  {804, "overcast"},
+	45: {741, "fog"},
+	48: {741, "depositing rime fog"},
+	51: {300, "light drizzle"},
+	53: {301, "moderate drizzle"},
+	55: {302, "dense drizzle"},
+	56: {511, "light freezing drizzle"},
+	57: {511, "dense freezing drizzle"},
+	61: {500, "slight rain"},
+	63: {501, "moderate rain"},
+	65: {502, "heavy rain"},
+	66: {511, "light freezing rain"},
+	67: {511, "heavy freezing rain"},
+	71: {600, "slight snow fall"},
+	73: {601, "moderate snow fall"},
+	75: {602, "heavy snow fall"},
+	77: {600, "snow grains"},
+	80: {520, "slight rain showers"},
+	81: {521, "moderate rain showers"},
+	82: {522, "violent rain showers"},
+	85: {620, "slight snow showers"},
+	86: {621, "heavy snow showers"},
+	95: {211, "thunderstorm"},
+	96: {201, "thunderstorm with slight hail"},
+	99: {202, "thunderstorm with heavy hail"},
+}
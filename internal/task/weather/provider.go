@@ -0,0 +1,95 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Provider is a weather data source.
+// Implementations turn provider-specific responses into a Forecast.
+type Provider interface {
+	// Name identifies the provider in config, cache keys and logs (e.g. "openweather").
+	Name() string
+	// Forecast returns current weather and active alerts for the request.
+	// Non-200 responses are reported as *StatusError.
+	Forecast(ctx context.Context, req Request) (Forecast, error)
+}
+
+// Request describes a weather query.
+type Request struct {
+	Lat float64
+	Lon float64
+	// Lang is a language code for descriptions (providers may ignore it).
+	Lang string
+	// Units is one of domain.UnitsMetric, domain.UnitsImperial or domain.UnitsStandard.
+	Units string
+}
+
+// Forecast is a provider-neutral snapshot of current weather and active alerts.
+// Numeric values are in the requested units.
+type Forecast struct {
+	Alerts []Alert `json:"alerts,omitempty"`
+	// AlertsSupported is set by providers that report alerts. Without it an empty Alerts
+	// says nothing about alerts that are already tracked.
+	AlertsSupported bool `json:"alerts_supported,omitempty"`
+	// WeatherID holds OpenWeather condition codes; other providers map their codes to them.
+	WeatherID []int `json:"weather_id,omitempty"`
+
+	Temp        float64 `json:"temp"`
+	FeelsLike   float64 `json:"feels_like"`
+	WindSpeed   float64 `json:"wind_speed"`
+	Description string  `json:"description,omitempty"`
+
+	// Minutely is the precipitation forecast for the next hour; empty when the provider has none.
+	Minutely []Precipitation `json:"minutely,omitempty"`
+
+	// Source is the name of the provider that produced the forecast.
+	Source string `json:"source,omitempty"`
+}
+
+// Precipitation is a one-minute precipitation forecast.
+type Precipitation struct {
+	// Time is the minute start (unix seconds).
+	Time int64 `json:"dt"`
+	// Value is the precipitation rate in mm/h regardless of units.
+	Value float64 `json:"precipitation"`
+}
+
+// Provider names accepted by NewProvider.
+const (
+	ProviderOpenWeather = "openweather"
+	ProviderOpenMeteo   = "openmeteo"
+)
+
+// NewProvider returns the provider with the given name.
+// owmOpts configure the OpenWeather client only.
+func NewProvider(name, apiKey string, owmOpts ...Option) (Provider, error) {
+	switch name {
+	case "", ProviderOpenWeather:
+		if apiKey == "" {
+			return nil, fmt.Errorf("openweather provider requires an API key")
+		}
+		return NewOpenWeatherClient(apiKey, owmOpts...), nil
+	case ProviderOpenMeteo:
+		return NewOpenMeteoClient(), nil
+	default:
+		return nil, fmt.Errorf("unknown weather provider %q", name)
+	}
+}
+
+func encodeForecast(f Forecast) ([]byte, error) {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, fmt.Errorf("encode forecast: %w", err)
+	}
+	return b, nil
+}
+
+func decodeForecast(b []byte) (Forecast, error) {
+	var f Forecast
+	if err := json.Unmarshal(b, &f); err != nil {
+		return Forecast{}, fmt.Errorf("decode forecast: %w", err)
+	}
+	return f, nil
+}
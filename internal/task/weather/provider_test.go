@@ -0,0 +1,172 @@
+package weather
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cron-weather/internal/domain"
+)
+
+// fixtureServer replays a recorded response for path and captures the request query.
+func fixtureServer(t *testing.T, path string, status int, fixture string) (*httptest.Server, *url.Values) {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &query
+}
+
+func TestOpenWeatherForecast(t *testing.T) {
+	srv, query := fixtureServer(t, "/data/3.0/onecall", http.StatusOK, "openweather_onecall.json")
+	c := NewOpenWeatherClient("test-key")
+	c.baseURL = srv.URL
+
+	f, err := c.Forecast(context.Background(), Request{Lat: 54.69, Lon: 25.28, Lang: "lt", Units: domain.UnitsMetric})
+	if err != nil {
+		t.Fatalf("Forecast: %v", err)
+	}
+
+	for k, want := range map[string]string{"lat": "54.690000", "lon": "25.280000", "lang": "lt", "units": "metric", "appid": "test-key"} {
+		if got := query.Get(k); got != want {
+			t.Errorf("query %s = %q, want %q", k, got, want)
+		}
+	}
+
+	if len(f.Alerts) != 1 || f.Alerts[0].Event != "Yellow wind warning" || f.Alerts[0].Tags[0] != "Wind" {
+		t.Errorf("alerts = %+v", f.Alerts)
+	}
+	if len(f.WeatherID) != 2 || f.WeatherID[0] != 212 || f.WeatherID[1] != 502 {
+		t.Errorf("weather ids = %v, want [212 502]", f.WeatherID)
+	}
+	if f.Temp != 7.42 || f.FeelsLike != 4.11 || f.WindSpeed != 6.3 {
+		t.Errorf("current = %+v", f)
+	}
+	if f.Description != "heavy thunderstorm" {
+		t.Errorf("description = %q", f.Description)
+	}
+	if len(f.Minutely) != 2 || f.Minutely[1].Time != 1792310460 || f.Minutely[1].Value != 0.42 {
+		t.Errorf("minutely = %+v", f.Minutely)
+	}
+}
+
+func TestOpenWeatherForecastError(t *testing.T) {
+	srv, _ := fixtureServer(t, "/data/3.0/onecall", http.StatusUnauthorized, "openweather_error_401.json")
+	c := NewOpenWeatherClient("bad-key")
+	c.baseURL = srv.URL
+
+	_, err := c.Forecast(context.Background(), Request{Lat: 54.69, Lon: 25.28})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "http=401") || !strings.Contains(err.Error(), "Invalid API key") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestOpenMeteoForecast(t *testing.T) {
+	tests := []struct {
+		units    string
+		tempUnit string
+		windUnit string
+		temp     float64
+	}{
+		{domain.UnitsMetric, "celsius", "ms", 7.4},
+		{domain.UnitsImperial, "fahrenheit", "mph", 7.4},
+		{domain.UnitsStandard, "celsius", "ms", 280.55},
+	}
+	for _, tt := range tests {
+		t.Run(tt.units, func(t *testing.T) {
+			srv, query := fixtureServer(t, "/v1/forecast", http.StatusOK, "openmeteo_forecast.json")
+			c := NewOpenMeteoClient()
+			c.baseURL = srv.URL
+
+			f, err := c.Forecast(context.Background(), Request{Lat: 54.69, Lon: 25.28, Lang: "en", Units: tt.units})
+			if err != nil {
+				t.Fatalf("Forecast: %v", err)
+			}
+
+			if got := query.Get("temperature_unit"); got != tt.tempUnit {
+				t.Errorf("temperature_unit = %q, want %q", got, tt.tempUnit)
+			}
+			if got := query.Get("wind_speed_unit"); got != tt.windUnit {
+				t.Errorf("wind_speed_unit = %q, want %q", got, tt.windUnit)
+			}
+			if got := query.Get("latitude"); got != "54.690000" {
+				t.Errorf("latitude = %q", got)
+			}
+
+			if math.Abs(f.Temp-tt.temp) > 1e-9 {
+				t.Errorf("temp = %v, want %v", f.Temp, tt.temp)
+			}
+			if f.WindSpeed != 6.3 {
+				t.Errorf("wind speed = %v", f.WindSpeed)
+			}
+			if len(f.Alerts) != 0 {
+				t.Errorf("alerts = %+v, want none", f.Alerts)
+			}
+			// WMO 99 (thunderstorm with heavy hail) maps to OpenWeather 202.
+			if len(f.WeatherID) != 1 || f.WeatherID[0] != 202 {
+				t.Errorf("weather ids = %v, want [202]", f.WeatherID)
+			}
+			if f.Description != "thunderstorm with heavy hail" {
+				t.Errorf("description = %q", f.Description)
+			}
+		})
+	}
+}
+
+func TestOpenMeteoForecastError(t *testing.T) {
+	srv, _ := fixtureServer(t, "/v1/forecast", http.StatusBadRequest, "openmeteo_error.json")
+	c := NewOpenMeteoClient()
+	c.baseURL = srv.URL
+
+	_, err := c.Forecast(context.Background(), Request{Lat: 91, Lon: 25.28})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "http=400") || !strings.Contains(err.Error(), "Latitude must be in range") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestForecastRoundTrip(t *testing.T) {
+	in := Forecast{
+		Alerts:      []Alert{{SenderName: "LHMT", Event: "Storm", Start: 1, End: 2, Tags: []string{"Wind"}}},
+		WeatherID:   []int{212},
+		Temp:        7.42,
+		FeelsLike:   4.11,
+		WindSpeed:   6.3,
+		Description: "heavy thunderstorm",
+	}
+	b, err := encodeForecast(in)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	out, err := decodeForecast(b)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.Alerts[0].Event != "Storm" || out.WeatherID[0] != 212 || out.Temp != 7.42 || out.Description != in.Description {
+		t.Errorf("round trip = %+v", out)
+	}
+}
@@ -0,0 +1,165 @@
+package weather
+
+import (
+	"strings"
+
+	"cron-weather/internal/domain"
+)
+
+// severityWord maps a keyword found in the alert event to a severity.
+type severityWord struct {
+	word     string
+	severity domain.Severity
+}
+
+// agencyScheme is the naming scheme of a national weather agency.
+// Rules are checked in order, so more specific words go first.
+type agencyScheme struct {
+	// senders are lower-case substrings of Alert.SenderName.
+	senders []string
+	words   []severityWord
+}
+
+// agencySchemes cover agencies that do not use colour levels.
+// US and Canadian alert names contain colours that mean something else ("Red Flag Warning").
+var agencySchemes = []agencyScheme{
+	{
+		// US National Weather Service: warning > watch > advisory > statement.
+		senders: []string{"nws", "national weather service"},
+		words: []severityWord{
+			{"tornado warning", domain.SeverityExtreme},
+			{"hurricane warning", domain.SeverityExtreme},
+			{"extreme wind warning", domain.SeverityExtreme},
+			{"flash flood emergency", domain.SeverityExtreme},
+			{"warning", domain.SeveritySevere},
+			{"watch", domain.SeverityModerate},
+			{"advisory", domain.SeverityMinor},
+			{"statement", domain.SeverityMinor},
+		},
+	},
+	{
+		// Environment and Climate Change Canada.
+		senders: []string{"environment canada", "environment and climate change canada", "eccc"},
+		words: []severityWord{
+			{"tornado warning", domain.SeverityExtreme},
+			{"warning", domain.SeveritySevere},
+			{"watch", domain.SeverityModerate},
+			{"advisory", domain.SeverityMinor},
+			{"statement", domain.SeverityMinor},
+		},
+	},
+}
+
+// colourWords cover MeteoAlarm members (LHMT, DWD, Met Office, Météo-France, ...) and Roshydromet,
+// which name warning levels by colour. A trailing "*" marks a word stem ("красн*" matches "красный").
+var colourWords = []severityWord{
+	{"red", domain.SeverityExtreme},
+	{"красн*", domain.SeverityExtreme},
+	{"raudon*", domain.SeverityExtreme},
+	{"orange", domain.SeveritySevere},
+	{"amber", domain.SeveritySevere},
+	{"оранж*", domain.SeveritySevere},
+	{"oranž*", domain.SeveritySevere},
+	{"yellow", domain.SeverityModerate},
+	{"желт*", domain.SeverityModerate},
+	{"жёлт*", domain.SeverityModerate},
+	{"gelton*", domain.SeverityModerate},
+	{"green", domain.SeverityMinor},
+	{"зелен*", domain.SeverityMinor},
+	{"žali*", domain.SeverityMinor},
+}
+
+// tagSeverities are used when the event name has no level.
+var tagSeverities = map[string]domain.Severity{
+	"fog":                      domain.SeverityMinor,
+	"air quality":              domain.SeverityMinor,
+	"other dangers":            domain.SeverityMinor,
+	"wind":                     domain.SeverityModerate,
+	"rain":                     domain.SeverityModerate,
+	"snow/ice":                 domain.SeverityModerate,
+	"thunderstorm":             domain.SeverityModerate,
+	"coastal event":            domain.SeverityModerate,
+	"extreme low temperature":  domain.SeveritySevere,
+	"extreme high temperature": domain.SeveritySevere,
+	"flood":                    domain.SeveritySevere,
+	"fire warning":             domain.SeveritySevere,
+	"avalanches":               domain.SeveritySevere,
+	"tornado":                  domain.SeverityExtreme,
+	"tropical cyclone":         domain.SeverityExtreme,
+}
+
+// alertSeverity derives a severity from the alert sender, event and tags.
+// Agency naming schemes win over colour levels, tags are the fallback and moderate is the default.
+func alertSeverity(a Alert) domain.Severity {
+	event := strings.ToLower(a.Event)
+	sender := strings.ToLower(a.SenderName)
+
+	for _, scheme := range agencySchemes {
+		if !containsAny(sender, scheme.senders) {
+			continue
+		}
+		if s, ok := matchWord(event, scheme.words); ok {
+			return s
+		}
+		break
+	}
+	if s, ok := matchWord(event, colourWords); ok {
+		return s
+	}
+
+	found := false
+	severity := domain.SeverityInfo
+	for _, tag := range a.Tags {
+		if s, ok := tagSeverities[strings.ToLower(strings.TrimSpace(tag))]; ok {
+			severity, found = max(severity, s), true
+		}
+	}
+	if found {
+		return severity
+	}
+	if strings.Contains(event, "fog") || strings.Contains(event, "туман") || strings.Contains(event, "rūk") {
+		return domain.SeverityMinor
+	}
+	return domain.SeverityModerate
+}
+
+func matchWord(s string, words []severityWord) (domain.Severity, bool) {
+	for _, w := range words {
+		if containsWord(s, w.word) {
+			return w.severity, true
+		}
+	}
+	return domain.SeverityInfo, false
+}
+
+// containsWord reports whether s contains the word w ("red" matches "red warning" but not
+// "reduced visibility"); a w ending with "*" is a stem and only needs a word start.
+func containsWord(s, w string) bool {
+	w, stem := strings.CutSuffix(w, "*")
+	for i := 0; ; {
+		j := strings.Index(s[i:], w)
+		if j < 0 {
+			return false
+		}
+		j += i
+		end := j + len(w)
+		if (j == 0 || !isLetter(s[j-1])) && (stem || end == len(s) || !isLetter(s[end])) {
+			return true
+		}
+		i = j + 1
+	}
+}
+
+// isLetter reports whether b is an ASCII letter or part of a multi-byte (non-ASCII) letter.
+func isLetter(b byte) bool {
+	return b >= 0x80 || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package weather
+
+import (
+	"testing"
+
+	"cron-weather/internal/domain"
+)
+
+func TestAlertSeverity(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Alert
+		want domain.Severity
+	}{
+		{"meteoalarm colour", Alert{SenderName: "Lithuanian Hydrometeorological Service", Event: "Yellow wind warning"}, domain.SeverityModerate},
+		{"orange", Alert{SenderName: "Met Office", Event: "Orange thunderstorm warning"}, domain.SeveritySevere},
+		{"red", Alert{SenderName: "Deutscher Wetterdienst", Event: "Red storm warning", Tags: []string{"Wind"}}, domain.SeverityExtreme},
+		{"russian colour", Alert{SenderName: "Росгидромет", Event: "Красный уровень опасности"}, domain.SeverityExtreme},
+		{"lithuanian colour", Alert{SenderName: "LHMT", Event: "Oranžinis pavojus"}, domain.SeveritySevere},
+		{"nws warning beats colour", Alert{SenderName: "NWS Boston MA", Event: "Red Flag Warning"}, domain.SeveritySevere},
+		{"nws advisory", Alert{SenderName: "NWS Boston MA", Event: "Dense Fog Advisory"}, domain.SeverityMinor},
+		{"nws tornado", Alert{SenderName: "NWS Norman OK", Event: "Tornado Warning"}, domain.SeverityExtreme},
+		{"canada watch", Alert{SenderName: "Environment Canada", Event: "Severe thunderstorm watch"}, domain.SeverityModerate},
+		{"word boundary", Alert{Event: "Reduced visibility", Tags: []string{"Fog"}}, domain.SeverityMinor},
+		{"tags", Alert{Event: "Weather warning", Tags: []string{"Wind", "Flood"}}, domain.SeveritySevere},
+		{"fog event", Alert{Event: "Fog"}, domain.SeverityMinor},
+		{"default", Alert{Event: "Weather warning"}, domain.SeverityModerate},
+	}
+	for _, tt := range tests {
+		if got := alertSeverity(tt.a); got != tt.want {
+			t.Errorf("%s: severity = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
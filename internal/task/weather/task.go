@@ -7,39 +7,95 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"sort"
 	"strings"
 	"time"
 
+	"cron-weather/internal/domain"
+	"cron-weather/internal/i18n"
+	"cron-weather/internal/render"
 	"cron-weather/internal/storage"
 	"cron-weather/internal/task"
 )
 
-// Task calls OpenWeather One Call API and produces messages.
+// Task fetches a forecast from a weather Provider and produces messages.
 // It is intentionally small and depends only on:
 //   - storage.Repo (for quota + dedup)
-//   - Client (for API call)
+//   - Provider (for API call)
 //
 // This makes replacing API/task logic easy.
 type Task struct {
 	log        *slog.Logger
 	repo       storage.Repo
-	client     *Client
+	provider   Provider
+	cache      *Cache
+	budget     *Budget
 	dailyLimit int
 
-	urgentCodes map[int]struct{}
+	urgentCodes    map[int]struct{}
+	urgentCooldown time.Duration
+	urgentAllClear bool
+
+	defaultLanguage string
+}
+
+// Options configures weather task behaviour.
+type Options struct {
+	// DailyLimit is the per-subscription API request cap.
+	DailyLimit int
+	// UrgentCooldown is the time bucket for urgent notice dedup (default 1h).
+	UrgentCooldown time.Duration
+	// UrgentAllClear sends an "all clear" message when urgent codes disappear.
+	UrgentAllClear bool
+	// DefaultLanguage is used for subscriptions without a language set.
+	DefaultLanguage string
+	// CacheTTL enables the shared response cache (0 disables fresh hits).
+	CacheTTL time.Duration
+	// SharedCache keeps cached responses in storage so replicas share them.
+	SharedCache bool
+	// Budget is the account-wide API budget used to degrade quiet places; nil disables it.
+	// Reservations are made by the provider wrapped with Budget.Wrap.
+	Budget *Budget
 }
 
+const (
+	defaultUrgentCooldown = time.Hour
+
+	// urgentActiveFingerprint marks that an urgent notice was delivered and not cleared yet.
+	urgentActiveFingerprint = "urgent:active"
+	// urgentBucketPrefix starts the fingerprints of urgent cooldown buckets, which are
+	// pruned once their bucket is over.
+	urgentBucketPrefix = "urgent:bucket:"
+)
+
 // NewTask constructs a weather task runner.
-func NewTask(log *slog.Logger, repo storage.Repo, client *Client, dailyLimit int) *Task {
+func NewTask(log *slog.Logger, repo storage.Repo, provider Provider, opts Options) *Task {
 	if log == nil {
 		log = slog.Default()
 	}
+	if opts.UrgentCooldown <= 0 {
+		opts.UrgentCooldown = defaultUrgentCooldown
+	}
 	t := &Task{
-		log:         log,
-		repo:        repo,
-		client:      client,
-		dailyLimit:  dailyLimit,
-		urgentCodes: map[int]struct{}{},
+		log:            log,
+		repo:           repo,
+		provider:       provider,
+		budget:         opts.Budget,
+		dailyLimit:     opts.DailyLimit,
+		urgentCodes:    map[int]struct{}{},
+		urgentCooldown: opts.UrgentCooldown,
+		urgentAllClear: opts.UrgentAllClear,
+
+		defaultLanguage: opts.DefaultLanguage,
+	}
+	// With a budget the cache also keeps responses for reuse while degraded,
+	// even when CacheTTL is 0 and fresh hits are disabled.
+	if opts.CacheTTL > 0 || opts.Budget != nil {
+		var store CacheStore
+		if opts.SharedCache && repo != nil {
+			store = repo
+		}
+		t.cache = NewCache(log, opts.CacheTTL, store)
 	}
 	for _, c := range []int{202, 212, 221, 232, 314, 504, 511, 522, 531, 602, 622, 761, 762, 771, 781} {
 		t.urgentCodes[c] = struct{}{}
@@ -50,86 +106,506 @@ func NewTask(log *slog.Logger, repo storage.Repo, client *Client, dailyLimit int
 // Run executes one weather check iteration and returns user-facing messages.
 func (t *Task) Run(ctx context.Context, in task.Input) (task.Result, error) {
 	// Ensure coordinates exist.
-	if in.Subscription.Lat == 0 && in.Subscription.Lon == 0 {
+	p, ok := target(in)
+	if !ok {
 		return task.Result{}, fmt.Errorf("subscription has no location; set it via /set_location <lat> <lon>")
 	}
 
+	oc, err := t.fetch(ctx, in, p)
+	if err != nil {
+		return task.Result{}, err
+	}
+
+	r := t.renderer(ctx, in)
+	var state pending
+
+	// Alerts -> messages with dedup. A source without alerts (e.g. a fallback provider)
+	// leaves tracked alerts alone instead of ending them.
+	var msgs []task.Message
+	if oc.AlertsSupported {
+		msgs, err = t.alertMessages(ctx, in, p, r, oc.Alerts, &state)
+		if err != nil {
+			return task.Result{}, err
+		}
+	}
+
+	// Urgent weather codes.
+	urgentIDs := make([]int, 0, 2)
+	for _, id := range oc.WeatherID {
+		if _, ok := t.urgentCodes[id]; ok {
+			urgentIDs = append(urgentIDs, id)
+		}
+	}
+	urgentMsgs, notified, err := t.urgentMessages(ctx, in, p, r, newUrgentView(oc, urgentIDs, p.Name), oc.AlertsSupported, &state)
+	if err != nil {
+		return task.Result{}, err
+	}
+	msgs = append(msgs, urgentMsgs...)
+	if len(urgentIDs) > 0 {
+		t.log.Warn("openweather urgent weather code",
+			slog.Any("ids", urgentIDs),
+			slog.Bool("notified", notified),
+			slog.String("subscription_id", in.Subscription.ID),
+			slog.String("location", p.Name),
+		)
+	}
+
+	fields := map[string]any{"source": oc.Source, "alerts_supported": oc.AlertsSupported}
+	if len(oc.Alerts) > 0 {
+		// A list, since concurrent alerts may share an event name.
+		severities := make([]alertSeverityField, 0, len(oc.Alerts))
+		for _, a := range oc.Alerts {
+			severities = append(severities, alertSeverityField{Event: a.Event, Sender: a.SenderName, Start: a.Start, Severity: alertSeverity(a).String()})
+		}
+		fields["alert_severities"] = severities
+	}
+	if len(urgentIDs) > 0 {
+		fields["urgent_weather_ids"] = urgentIDs
+		fields["urgent_notified"] = notified
+	}
+	b, _ := json.Marshal(fields)
+	payload := string(b)
+
+	for i := range msgs {
+		msgs[i].ParseMode = r.opts.ParseMode
+	}
+	return task.Result{Messages: msgs, Payload: payload, Commit: state.commit()}, nil
+}
+
+// alertSeverityField is one entry of the "alert_severities" run payload field.
+type alertSeverityField struct {
+	Event    string `json:"event"`
+	Sender   string `json:"sender,omitempty"`
+	Start    int64  `json:"start,omitempty"`
+	Severity string `json:"severity"`
+}
+
+// pending collects dedup state changes that are recorded once the messages of a run
+// are handed off, so a failed hand-off produces the messages again on the next run.
+type pending []func(ctx context.Context) error
+
+func (p *pending) add(fn func(ctx context.Context) error) {
+	*p = append(*p, fn)
+}
+
+// commit returns a function applying the changes in order, or nil when there are none.
+func (p pending) commit() func(ctx context.Context) error {
+	if len(p) == 0 {
+		return nil
+	}
+	return func(ctx context.Context) error {
+		for _, fn := range p {
+			if err := fn(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// language returns the subscription language or the task default.
+func (t *Task) language(in task.Input) string {
+	return language(in, t.defaultLanguage)
+}
+
+// language returns the subscription language, falling back to def.
+func language(in task.Input, def string) string {
+	if in.Subscription.Language != "" {
+		return in.Subscription.Language
+	}
+	if def != "" {
+		return def
+	}
+	return i18n.Russian
+}
+
+// fetch returns the forecast for p, served from the cache when it is fresh.
+// On a cache miss the subscription's daily quota is reserved before the upstream call, so every
+// subscription is charged and rejected on its own; concurrent identical requests still share one
+// upstream call, and the account budget is reserved once for it.
+// While the budget is low, a quiet place (no alerts, no urgent codes) reuses older responses.
+func (t *Task) fetch(ctx context.Context, in task.Input, p place) (Forecast, error) {
+	req := Request{Lat: roundCoord(p.Lat), Lon: roundCoord(p.Lon), Lang: t.language(in), Units: units(in)}
+	key := cacheKey(t.provider.Name(), req.Lat, req.Lon, req.Lang, req.Units)
+
+	if t.cache != nil && t.budget != nil {
+		if raw, ok := t.cache.Lookup(ctx, key, t.budget.DegradeInterval()); ok {
+			if f, err := decodeForecast(raw); err == nil && !t.eventful(f) && t.budget.Degraded(ctx) {
+				t.log.Info("openweather budget low, reusing cached response",
+					slog.String("subscription_id", in.Subscription.ID),
+					slog.String("location", p.Name),
+				)
+				return f, nil
+			}
+		}
+	}
+
+	if t.cache != nil {
+		if raw, ok := t.cache.Fresh(ctx, key); ok {
+			return decodeForecast(raw)
+		}
+	}
 	if t.repo != nil {
 		ok, used, err := t.repo.ReserveDailyUsage(ctx, in.Subscription.ID, time.Now(), t.dailyLimit)
 		if err != nil {
-			return task.Result{}, err
+			return Forecast{}, err
 		}
 		if !ok {
-			return task.Result{}, fmt.Errorf("daily limit exceeded (%d/%d)", used, t.dailyLimit)
+			return Forecast{}, fmt.Errorf("daily limit exceeded (%d/%d)", used, t.dailyLimit)
 		}
 	}
 
-	oc, status, hdr, raw, err := t.client.OneCall(ctx, in.Subscription.Lat, in.Subscription.Lon)
+	upstream := func(ctx context.Context) ([]byte, error) {
+		f, err := t.provider.Forecast(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if f.Source == "" {
+			f.Source = t.provider.Name()
+		}
+		return encodeForecast(f)
+	}
+
+	var raw []byte
+	var err error
+	if t.cache != nil {
+		raw, err = t.cache.Fetch(ctx, key, upstream)
+	} else {
+		raw, err = upstream(ctx)
+	}
 	if err != nil {
-		return task.Result{}, err
+		return Forecast{}, err
 	}
+	return decodeForecast(raw)
+}
 
-	if status != 200 {
-		if apiErr, ok := DecodeAPIError(raw); ok {
-			return task.Result{}, fmt.Errorf("openweather error: http=%d cod=%d message=%q parameters=%v", status, apiErr.codeInt(), apiErr.Message, apiErr.Parameters)
+// eventful reports whether the response has alerts or urgent weather codes.
+func (t *Task) eventful(oc Forecast) bool {
+	if len(oc.Alerts) > 0 {
+		return true
+	}
+	for _, id := range oc.WeatherID {
+		if _, ok := t.urgentCodes[id]; ok {
+			return true
 		}
-		preview := string(raw)
-		if len(preview) > 300 {
-			preview = preview[:300] + "..."
+	}
+	return false
+}
+
+// place is the resolved weather target of one run.
+type place struct {
+	// Key scopes alert dedup: location ID, or "" for subscription coordinates.
+	Key  string
+	Name string
+	Lat  float64
+	Lon  float64
+}
+
+// target returns the schedule location, falling back to the subscription coordinates.
+func target(in task.Input) (place, bool) {
+	if in.Location != nil {
+		return place{Key: in.Location.ID, Name: in.Location.Name, Lat: in.Location.Lat, Lon: in.Location.Lon}, true
+	}
+	lat, lon, ok := in.Subscription.Coordinates()
+	if !ok {
+		return place{}, false
+	}
+	return place{Lat: lat, Lon: lon}, true
+}
+
+// units returns the subscription measurement units (metric by default).
+func units(in task.Input) string {
+	if in.Subscription.Units != "" {
+		return in.Subscription.Units
+	}
+	return domain.UnitsMetric
+}
+
+// renderer renders templates for one run with subscription overrides applied.
+type renderer struct {
+	log  *slog.Logger
+	opts render.Options
+}
+
+func (t *Task) renderer(ctx context.Context, in task.Input) renderer {
+	return newRenderer(ctx, t.log, t.repo, in, t.language(in))
+}
+
+func newRenderer(ctx context.Context, log *slog.Logger, repo storage.Repo, in task.Input, lang string) renderer {
+	loc, err := time.LoadLocation(strings.TrimSpace(in.Scheduler.TZ))
+	if err != nil || in.Scheduler.TZ == "" {
+		loc = time.UTC
+	}
+	r := renderer{
+		log:  log.With(slog.String("subscription_id", in.Subscription.ID)),
+		opts: render.Options{ParseMode: in.Subscription.ParseMode, Location: loc, Lang: lang, Units: units(in)},
+	}
+	if repo != nil {
+		overrides, err := repo.ListTemplates(ctx, in.Subscription.ID)
+		if err != nil {
+			r.log.Warn("failed to load message templates", slog.Any("err", err))
 		}
-		return task.Result{}, fmt.Errorf("openweather error: http=%d body=%q", status, preview)
+		r.opts.Overrides = overrides
+	}
+	return r
+}
+
+// render executes a template; a broken override is logged and replaced by the built-in template.
+func (r renderer) render(name string, data any) (string, error) {
+	out, err := render.Render(name, data, r.opts)
+	if err != nil && out != "" {
+		r.log.Warn("message template override failed", slog.String("template", name), slog.Any("err", err))
+		return out, nil
 	}
+	return out, err
+}
 
-	// Alerts -> messages with dedup.
-	var msgs []string
-	for _, a := range oc.Alerts {
-		fp := alertFingerprint(a)
-		send := true
-		if t.repo != nil {
-			inserted, derr := t.repo.MarkAlertSent(ctx, in.Subscription.ID, fp)
-			if derr != nil {
-				return task.Result{}, derr
+// alertMessages turns alerts into new/updated/ended messages.
+//
+// Alert identity (sender, event, start) is tracked separately from alert content:
+// a known alert with changed content produces an "updated" message, a previously
+// delivered alert missing from the response produces an "ended" message, and an
+// ended alert that comes back is announced again. State changes are added to state.
+func (t *Task) alertMessages(ctx context.Context, in task.Input, p place, r renderer, alerts []Alert, state *pending) ([]task.Message, error) {
+	if t.repo == nil {
+		var msgs []task.Message
+		for _, a := range alerts {
+			severity := alertSeverity(a)
+			if severity < in.Subscription.MinSeverity {
+				continue
+			}
+			m, err := r.render(render.Alert, newAlertView(a, p.Name))
+			if err != nil {
+				return nil, err
 			}
-			send = inserted
+			msgs = append(msgs, task.Message{Text: m, Severity: severity})
+		}
+		return digest(r, msgs)
+	}
+
+	seen := make(map[string]bool, len(alerts))
+	keys := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		key := alertKey(a)
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	states, err := t.repo.ListAlertStates(ctx, in.Subscription.ID, p.Key, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []task.Message
+	for _, a := range alerts {
+		severity := alertSeverity(a)
+		// Alerts below the subscription minimum are neither delivered nor tracked.
+		if severity < in.Subscription.MinSeverity {
+			continue
 		}
-		if !send {
+		key, fp := alertKey(a), alertFingerprint(a)
+		st, known := states[key]
+
+		var prev Alert
+		hasPrev := known && st.Active && json.Unmarshal([]byte(st.Snapshot), &prev) == nil
+		if hasPrev && alertFingerprint(prev) == fp {
 			continue
 		}
 
-		msg := fmt.Sprintf("[%s] %s: %s (с %s до %s). Теги: %v",
-			a.SenderName,
-			a.Event,
-			strings.TrimSpace(a.Description),
-			time.Unix(a.Start, 0).Format("02.01.2006 15:04"),
-			time.Unix(a.End, 0).Format("02.01.2006 15:04"),
-			a.Tags,
-		)
-		msgs = append(msgs, msg)
+		snapshot, _ := json.Marshal(a)
+		state.add(func(ctx context.Context) error {
+			if err := t.repo.SaveAlertState(ctx, in.Subscription.ID, p.Key, key, string(snapshot)); err != nil {
+				return err
+			}
+			_, err := t.repo.MarkAlertSent(ctx, in.Subscription.ID, p.Key, fp)
+			return err
+		})
+
+		if hasPrev {
+			v := newAlertView(a, p.Name)
+			v.Changes = alertChanges(prev, a, r.opts.Location, r.opts.Lang)
+			m, err := r.render(render.AlertUpdated, v)
+			if err != nil {
+				return nil, err
+			}
+			msgs = append(msgs, task.Message{Text: m, Severity: severity})
+			continue
+		}
+		// Content delivered before identity tracking existed: only record state.
+		if !known {
+			sent, err := t.repo.HasAlertSent(ctx, in.Subscription.ID, p.Key, fp)
+			if err != nil {
+				return nil, err
+			}
+			if sent {
+				continue
+			}
+		}
+		m, err := r.render(render.Alert, newAlertView(a, p.Name))
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, task.Message{Text: m, Severity: severity})
 	}
 
-	// Urgent weather codes.
-	urgentIDs := make([]int, 0, 2)
-	for _, id := range oc.WeatherID {
-		if _, ok := t.urgentCodes[id]; ok {
-			urgentIDs = append(urgentIDs, id)
+	var ended []string
+	for key, st := range states {
+		if st.Active && !seen[key] {
+			ended = append(ended, key)
 		}
 	}
-	if len(urgentIDs) > 0 {
-		msgs = append(msgs, "позвони срочно родителям")
-		t.log.Warn("openweather urgent weather code",
-			slog.Any("ids", urgentIDs),
-			slog.String("x_request_id", hdr.Get("X-Request-Id")),
-			slog.String("subscription_id", in.Subscription.ID),
-		)
+	sort.Strings(ended)
+	for _, key := range ended {
+		st := states[key]
+		var a Alert
+		if json.Unmarshal([]byte(st.Snapshot), &a) != nil {
+			continue
+		}
+		m, err := r.render(render.AlertEnded, newAlertView(a, p.Name))
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, task.Message{Text: m, Severity: domain.SeverityInfo})
+	}
+	if len(ended) > 0 {
+		state.add(func(ctx context.Context) error {
+			return t.repo.EndAlerts(ctx, in.Subscription.ID, p.Key, ended)
+		})
+	}
+	return digest(r, msgs)
+}
+
+// digest merges several alert messages of one run into a single message
+// with the highest severity of its items.
+func digest(r renderer, msgs []task.Message) ([]task.Message, error) {
+	if len(msgs) < 2 {
+		return msgs, nil
+	}
+	items := make([]string, 0, len(msgs))
+	severity := domain.SeverityInfo
+	for _, m := range msgs {
+		items = append(items, m.Text)
+		severity = max(severity, m.Severity)
 	}
+	m, err := r.render(render.Digest, digestView{Items: items})
+	if err != nil {
+		return nil, err
+	}
+	return []task.Message{{Text: m, Severity: severity}}, nil
+}
 
-	payload := ""
-	if len(urgentIDs) > 0 {
-		b, _ := json.Marshal(map[string]any{"urgent_weather_ids": urgentIDs})
-		payload = string(b)
+// urgentMessages decides whether urgent codes should produce a notice.
+// Repeats of the same code set are suppressed within one cooldown bucket; when the
+// urgent condition ends an optional "all clear" message is produced. The condition is
+// only considered ended by a source that also reports alerts (canClear), so a fallback
+// provider does not clear it. State changes are added to state.
+func (t *Task) urgentMessages(ctx context.Context, in task.Input, p place, r renderer, v urgentView, canClear bool, state *pending) ([]task.Message, bool, error) {
+	if len(v.Codes) == 0 {
+		if t.repo == nil || !canClear {
+			return nil, false, nil
+		}
+		active, err := t.repo.HasAlertSent(ctx, in.Subscription.ID, p.Key, urgentActiveFingerprint)
+		if err != nil || !active {
+			return nil, false, err
+		}
+		state.add(func(ctx context.Context) error {
+			if _, err := t.repo.ClearAlertSent(ctx, in.Subscription.ID, p.Key, urgentActiveFingerprint); err != nil {
+				return err
+			}
+			return t.pruneUrgentBuckets(ctx, in, p)
+		})
+		if t.urgentAllClear {
+			m, err := r.render(render.AllClear, v)
+			if err != nil {
+				return nil, false, err
+			}
+			return []task.Message{{Text: m, Severity: domain.SeverityInfo}}, false, nil
+		}
+		return nil, false, nil
 	}
 
-	return task.Result{Messages: msgs, Payload: payload}, nil
+	if t.repo == nil {
+		m, err := r.render(render.Urgent, v)
+		if err != nil {
+			return nil, false, err
+		}
+		return []task.Message{{Text: m, Severity: domain.SeveritySevere}}, true, nil
+	}
+
+	fp := urgentFingerprint(v.Codes, in.ScheduledFor.Truncate(t.urgentCooldown))
+	sent, err := t.repo.HasAlertSent(ctx, in.Subscription.ID, p.Key, fp)
+	if err != nil {
+		return nil, false, err
+	}
+	state.add(func(ctx context.Context) error {
+		inserted, err := t.repo.MarkAlertSent(ctx, in.Subscription.ID, p.Key, fp)
+		if err != nil {
+			return err
+		}
+		if _, err := t.repo.MarkAlertSent(ctx, in.Subscription.ID, p.Key, urgentActiveFingerprint); err != nil {
+			return err
+		}
+		if !inserted {
+			return nil
+		}
+		return t.pruneUrgentBuckets(ctx, in, p)
+	})
+	if sent {
+		return nil, false, nil
+	}
+	m, err := r.render(render.Urgent, v)
+	if err != nil {
+		return nil, false, err
+	}
+	return []task.Message{{Text: m, Severity: domain.SeveritySevere}}, true, nil
+}
+
+// pruneUrgentBuckets removes urgent bucket fingerprints older than one cooldown;
+// their buckets are over and can no longer suppress a notice.
+func (t *Task) pruneUrgentBuckets(ctx context.Context, in task.Input, p place) error {
+	return t.repo.PruneAlertsSent(ctx, in.Subscription.ID, p.Key, urgentBucketPrefix, time.Now().Add(-t.urgentCooldown))
+}
+
+func urgentFingerprint(ids []int, bucket time.Time) string {
+	// Stable fingerprint: sha256(json(sorted unique codes, bucket start))
+	codes := make([]int, 0, len(ids))
+	seen := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		codes = append(codes, id)
+	}
+	sort.Ints(codes)
+
+	b, _ := json.Marshal(struct {
+		Kind   string `json:"kind"`
+		Codes  []int  `json:"codes"`
+		Bucket int64  `json:"bucket"`
+	}{
+		Kind:   "urgent",
+		Codes:  codes,
+		Bucket: bucket.Unix(),
+	})
+
+	sum := sha256.Sum256(b)
+	return urgentBucketPrefix + hex.EncodeToString(sum[:])
+}
+
+// alertKey identifies an alert independently of its content: sha256(json(sender,event,start)).
+func alertKey(a Alert) string {
+	b, _ := json.Marshal(struct {
+		SenderName string `json:"sender_name"`
+		Event      string `json:"event"`
+		Start      int64  `json:"start"`
+	}{
+		SenderName: a.SenderName,
+		Event:      a.Event,
+		Start:      a.Start,
+	})
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
 }
 
 func alertFingerprint(a Alert) string {
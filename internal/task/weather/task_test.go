@@ -0,0 +1,330 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cron-weather/internal/domain"
+	"cron-weather/internal/storage"
+	"cron-weather/internal/task"
+)
+
+// memRepo keeps the weather task state of one subscription in memory.
+// Methods the weather task does not use panic through the nil embedded Repo.
+type memRepo struct {
+	storage.Repo
+
+	mu     sync.Mutex
+	states map[string]domain.AlertState
+	sent   map[string]bool
+	sentAt map[string]time.Time
+	usage  map[string]int
+	budget domain.BudgetUsage
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{states: map[string]domain.AlertState{}, sent: map[string]bool{}, sentAt: map[string]time.Time{}, usage: map[string]int{}}
+}
+
+func (r *memRepo) ListTemplates(context.Context, string) (map[string]string, error) { return nil, nil }
+
+func (r *memRepo) ReserveDailyUsage(_ context.Context, subscriptionID string, _ time.Time, limit int) (bool, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if limit > 0 && r.usage[subscriptionID] >= limit {
+		return false, r.usage[subscriptionID], nil
+	}
+	r.usage[subscriptionID]++
+	return true, r.usage[subscriptionID], nil
+}
+
+func (r *memRepo) GetBudgetUsage(context.Context, time.Time) (domain.BudgetUsage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.budget, nil
+}
+
+func (r *memRepo) HasAlertSent(_ context.Context, _, locationKey, fingerprint string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sent[locationKey+"/"+fingerprint], nil
+}
+
+func (r *memRepo) MarkAlertSent(_ context.Context, _, locationKey, fingerprint string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := locationKey + "/" + fingerprint
+	inserted := !r.sent[k]
+	if inserted {
+		r.sentAt[k] = time.Now()
+	}
+	r.sent[k] = true
+	return inserted, nil
+}
+
+func (r *memRepo) PruneAlertsSent(_ context.Context, _, locationKey, prefix string, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k := range r.sent {
+		if strings.HasPrefix(k, locationKey+"/"+prefix) && r.sentAt[k].Before(before) {
+			delete(r.sent, k)
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) ClearAlertSent(_ context.Context, _, locationKey, fingerprint string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := locationKey + "/" + fingerprint
+	existed := r.sent[k]
+	delete(r.sent, k)
+	return existed, nil
+}
+
+func (r *memRepo) ListAlertStates(_ context.Context, _, _ string, keys []string) (map[string]domain.AlertState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := map[string]domain.AlertState{}
+	for k, st := range r.states {
+		if st.Active {
+			out[k] = st
+		}
+	}
+	for _, k := range keys {
+		if st, ok := r.states[k]; ok {
+			out[k] = st
+		}
+	}
+	return out, nil
+}
+
+func (r *memRepo) SaveAlertState(_ context.Context, _, _, alertKey, snapshot string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[alertKey] = domain.AlertState{Key: alertKey, Snapshot: snapshot, Active: true}
+	return nil
+}
+
+func (r *memRepo) EndAlerts(_ context.Context, _, _ string, keys []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range keys {
+		st := r.states[k]
+		st.Active = false
+		r.states[k] = st
+	}
+	return nil
+}
+
+// stubProvider returns a fixed forecast.
+type stubProvider struct {
+	name  string
+	f     Forecast
+	calls int
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) Forecast(context.Context, Request) (Forecast, error) {
+	p.calls++
+	return p.f, nil
+}
+
+func testInput() task.Input {
+	lat, lon := 54.69, 25.28
+	return task.Input{
+		Subscription: domain.Subscription{ID: "sub", Lat: &lat, Lon: &lon, Language: "en"},
+		ScheduledFor: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// run runs the task once; commit applies its state as the runtime does after a hand-off.
+func run(t *testing.T, wt *Task, commit bool) []string {
+	t.Helper()
+	res, err := wt.Run(context.Background(), testInput())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if commit && res.Commit != nil {
+		if err := res.Commit(context.Background()); err != nil {
+			t.Fatalf("Commit: %v", err)
+		}
+	}
+	texts := make([]string, 0, len(res.Messages))
+	for _, m := range res.Messages {
+		texts = append(texts, m.Text)
+	}
+	return texts
+}
+
+var testAlert = Alert{SenderName: "LHMT", Event: "Wind warning", Start: 1767268800, End: 1767290400, Description: "Gusts up to 25 m/s"}
+
+func TestAlertLifecycle(t *testing.T) {
+	repo := newMemRepo()
+	p := &stubProvider{name: "stub", f: Forecast{Alerts: []Alert{testAlert}, AlertsSupported: true}}
+	wt := NewTask(nil, repo, p, Options{})
+
+	if got := run(t, wt, true); len(got) != 1 || !strings.Contains(got[0], "Wind warning") {
+		t.Fatalf("new alert: messages = %q", got)
+	}
+	if got := run(t, wt, true); len(got) != 0 {
+		t.Fatalf("repeated alert: messages = %q, want none", got)
+	}
+
+	p.f.Alerts = nil
+	if got := run(t, wt, true); len(got) != 1 || !strings.Contains(got[0], "Ended") {
+		t.Fatalf("ended alert: messages = %q", got)
+	}
+
+	// The same alert coming back is announced again.
+	p.f.Alerts = []Alert{testAlert}
+	if got := run(t, wt, true); len(got) != 1 || strings.Contains(got[0], "Ended") {
+		t.Fatalf("reactivated alert: messages = %q", got)
+	}
+}
+
+func TestAlertStateRecordedAfterHandOff(t *testing.T) {
+	repo := newMemRepo()
+	p := &stubProvider{name: "stub", f: Forecast{Alerts: []Alert{testAlert}, AlertsSupported: true}}
+	wt := NewTask(nil, repo, p, Options{})
+
+	// The first hand-off fails: nothing is recorded and the alert is produced again.
+	if got := run(t, wt, false); len(got) != 1 {
+		t.Fatalf("first run: messages = %q", got)
+	}
+	if len(repo.states) != 0 || len(repo.sent) != 0 {
+		t.Fatalf("state recorded before hand-off: %v %v", repo.states, repo.sent)
+	}
+	if got := run(t, wt, true); len(got) != 1 {
+		t.Fatalf("second run: messages = %q, want the alert again", got)
+	}
+	if got := run(t, wt, true); len(got) != 0 {
+		t.Fatalf("third run: messages = %q, want none", got)
+	}
+}
+
+func TestAlertSeveritiesPayload(t *testing.T) {
+	second := testAlert
+	second.Start += 3600
+	second.Description = "Gusts up to 30 m/s"
+	p := &stubProvider{name: "stub", f: Forecast{Alerts: []Alert{testAlert, second}, AlertsSupported: true}}
+	wt := NewTask(nil, newMemRepo(), p, Options{})
+
+	res, err := wt.Run(context.Background(), testInput())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	var payload struct {
+		Severities []alertSeverityField `json:"alert_severities"`
+	}
+	if err := json.Unmarshal([]byte(res.Payload), &payload); err != nil {
+		t.Fatalf("payload %q: %v", res.Payload, err)
+	}
+	if len(payload.Severities) != 2 {
+		t.Errorf("alert_severities = %+v, want both alerts with the same event", payload.Severities)
+	}
+}
+
+// blockingProvider blocks every call until release is closed.
+type blockingProvider struct {
+	entered chan struct{}
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (p *blockingProvider) Name() string { return "blocking" }
+
+func (p *blockingProvider) Forecast(ctx context.Context, _ Request) (Forecast, error) {
+	if p.calls.Add(1) == 1 {
+		close(p.entered)
+	}
+	select {
+	case <-p.release:
+		return Forecast{Temp: 1}, nil
+	case <-ctx.Done():
+		return Forecast{}, ctx.Err()
+	}
+}
+
+func TestCoalescedFetchIsPerSubscription(t *testing.T) {
+	repo := newMemRepo()
+	p := &blockingProvider{entered: make(chan struct{}), release: make(chan struct{})}
+	wt := NewTask(nil, repo, p, Options{CacheTTL: time.Minute, DailyLimit: 1})
+
+	inA, inB, inC := testInput(), testInput(), testInput()
+	inA.Subscription.ID, inB.Subscription.ID, inC.Subscription.ID = "a", "b", "c"
+	repo.usage["c"] = 1 // c has no quota left
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	errA := make(chan error, 1)
+	go func() {
+		_, err := wt.Run(ctxA, inA)
+		errA <- err
+	}()
+	<-p.entered
+
+	errB := make(chan error, 1)
+	go func() {
+		_, err := wt.Run(context.Background(), inB)
+		errB <- err
+	}()
+	if _, err := wt.Run(context.Background(), inC); err == nil {
+		t.Error("c: expected daily limit error")
+	}
+
+	// The caller that started the shared call goes away; the others still get the forecast.
+	time.Sleep(20 * time.Millisecond)
+	cancelA()
+	if err := <-errA; err == nil {
+		t.Error("a: expected context error")
+	}
+	close(p.release)
+	if err := <-errB; err != nil {
+		t.Errorf("b: %v", err)
+	}
+
+	if p.calls.Load() != 1 {
+		t.Errorf("provider calls = %d, want 1", p.calls.Load())
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if repo.usage[id] != 1 {
+			t.Errorf("usage[%s] = %d, want 1", id, repo.usage[id])
+		}
+	}
+}
+
+func TestDegradedBudgetReusesResponseWithoutCache(t *testing.T) {
+	repo := newMemRepo()
+	budget := NewBudget(nil, repo, BudgetOptions{Daily: 10, DegradeInterval: time.Hour})
+	p := &stubProvider{name: "stub", f: Forecast{Temp: 1}}
+	wt := NewTask(nil, repo, p, Options{Budget: budget})
+
+	run(t, wt, true)
+	run(t, wt, true)
+	if p.calls != 2 {
+		t.Fatalf("provider calls = %d, want 2 below the warning threshold", p.calls)
+	}
+
+	// Usage recorded by another replica degrades this one as well.
+	repo.budget.DayUsed = 8
+	run(t, wt, true)
+	if p.calls != 2 {
+		t.Errorf("provider calls = %d, want the quiet place served from the last response", p.calls)
+	}
+
+	// Places with urgent weather keep their normal frequency.
+	repo.budget.DayUsed = 0
+	p.f.WeatherID = []int{202}
+	run(t, wt, true)
+	repo.budget.DayUsed = 8
+	run(t, wt, true)
+	if p.calls != 4 {
+		t.Errorf("provider calls = %d, want 4 for an eventful place", p.calls)
+	}
+}
@@ -0,0 +1,35 @@
+package transport
+
+import "strings"
+
+// Escape escapes s for the given parse mode; plain text is returned as is.
+func Escape(parseMode, s string) string {
+	switch parseMode {
+	case ParseModeHTML:
+		return EscapeHTML(s)
+	case ParseModeMarkdownV2:
+		return EscapeMarkdownV2(s)
+	default:
+		return s
+	}
+}
+
+// EscapeHTML escapes the characters Telegram HTML requires to be escaped.
+func EscapeHTML(s string) string { return htmlEscaper.Replace(s) }
+
+// EscapeMarkdownV2 escapes all MarkdownV2 reserved characters.
+func EscapeMarkdownV2(s string) string { return markdownEscaper.Replace(s) }
+
+// EscapeMarkdownV2Code escapes text placed inside MarkdownV2 code or pre entities,
+// where only backslash and backtick are special.
+func EscapeMarkdownV2Code(s string) string { return markdownCodeEscaper.Replace(s) }
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+var markdownCodeEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
@@ -0,0 +1,26 @@
+package transport
+
+import "testing"
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		parseMode string
+		in, want  string
+	}{
+		{"", "<b>1.5_x</b>", "<b>1.5_x</b>"},
+		{ParseModeHTML, `a<b>&c "d"`, `a&lt;b&gt;&amp;c "d"`},
+		{ParseModeMarkdownV2, "1.5_x*[y](z)", `1\.5\_x\*\[y\]\(z\)`},
+		{ParseModeMarkdownV2, `-+=|{}!#>~` + "`" + `\`, `\-\+\=\|\{\}\!\#\>\~` + "\\`" + `\\`},
+	}
+	for _, tt := range tests {
+		if got := Escape(tt.parseMode, tt.in); got != tt.want {
+			t.Errorf("Escape(%q, %q) = %q, want %q", tt.parseMode, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeMarkdownV2Code(t *testing.T) {
+	if got, want := EscapeMarkdownV2Code("a`b\\c_d.e"), "a\\`b\\\\c_d.e"; got != want {
+		t.Errorf("EscapeMarkdownV2Code = %q, want %q", got, want)
+	}
+}
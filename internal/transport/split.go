@@ -0,0 +1,433 @@
+package transport
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxMessageLength is the Telegram limit for message text, in UTF-16 code units.
+const MaxMessageLength = 4096
+
+// entity is formatting open at some point of the text; split parts close it and reopen it.
+type entity struct {
+	open, close string
+}
+
+// markup describes the formatting state at every byte offset of a text.
+type markup struct {
+	// safe[i] reports whether the text may be cut before byte i.
+	safe []bool
+	// stack[i] indexes stacks with the entities open before byte i.
+	stack  []int
+	stacks [][]entity
+}
+
+func (m *markup) open(i int) []entity { return m.stacks[m.stack[i]] }
+
+// Split cuts text into parts of at most limit UTF-16 code units.
+//
+// Cuts are made at paragraph breaks first, then at line breaks, then at spaces. With
+// HTML or MarkdownV2 a cut never falls inside a tag, an HTML entity or an escape
+// sequence, and formatting open at the cut is closed and reopened in the next part.
+// Formatting whose markup takes more than half of a part is dropped, see dropLongMarkup.
+func Split(text, parseMode string, limit int) []string {
+	if limit <= 0 {
+		limit = MaxMessageLength
+	}
+	if utf16Len(text) <= limit {
+		return []string{text}
+	}
+
+	text = dropLongMarkup(text, parseMode, limit)
+	m := scan(text, parseMode)
+	var parts []string
+	start := 0
+	for start < len(text) {
+		prefix := openers(m.open(start))
+		cut := m.cut(text, start, limit-utf16Len(prefix))
+		part := prefix + strings.TrimRight(text[start:cut], " \n") + closers(m.open(cut))
+		if strings.TrimSpace(part) != "" {
+			parts = append(parts, part)
+		}
+		start = cut
+		for start < len(text) && (text[start] == ' ' || text[start] == '\n') && m.safe[start+1] {
+			start++
+		}
+	}
+	return parts
+}
+
+// SplitMessage splits the text of msg with Split. The keyboard goes with the last part;
+// an edit replaces one message, so its text is not split.
+func SplitMessage(msg Message, limit int) []Message {
+	if msg.EditMessageID != 0 {
+		return []Message{msg}
+	}
+	texts := Split(msg.Text, msg.ParseMode, limit)
+	out := make([]Message, len(texts))
+	for i, text := range texts {
+		out[i] = msg
+		out[i].Text = text
+		if i < len(texts)-1 {
+			out[i].Keyboard = nil
+		}
+	}
+	return out
+}
+
+// NewSplitter returns a producer that sends each part of a long message through p.
+// Messages are split in one layer only: the outbox queue when it is used, a splitter otherwise.
+func NewSplitter(p Producer) Producer {
+	return splitter{p: p}
+}
+
+type splitter struct {
+	p Producer
+}
+
+func (s splitter) Send(ctx context.Context, msg Message) error {
+	for _, part := range SplitMessage(msg, MaxMessageLength) {
+		if err := s.p.Send(ctx, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cut returns the end of the part starting at start that fits in budget.
+// It prefers the last paragraph break, line break and space, in that order.
+func (m *markup) cut(text string, start, budget int) int {
+	var para, line, space, any, hard int
+	n := 0
+	for i := start; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		n += runeUTF16Len(r)
+		i += size
+		if n > budget {
+			break
+		}
+		hard = i
+		if i < len(text) && !m.safe[i] {
+			continue
+		}
+		if n+utf16Len(closers(m.open(i))) > budget {
+			continue
+		}
+		any = i
+		if i == len(text) {
+			return i
+		}
+		switch {
+		case text[i] == '\n' && i > start && text[i-1] == '\n':
+			para = i
+		case text[i] == '\n':
+			line = i
+		case text[i] == ' ':
+			space = i
+		}
+	}
+	for _, c := range []int{para, line, space, any, hard} {
+		if c > start {
+			return c
+		}
+	}
+	// Not even one rune fits next to the reopened formatting; make progress anyway.
+	_, size := utf8.DecodeRuneInString(text[start:])
+	return start + size
+}
+
+// dropLongMarkup removes markup too long to be kept whole in a part and reopened in the
+// next one: an HTML opening tag (e.g. <a href> with a long URL) goes with its closing tag,
+// and a MarkdownV2 link is replaced with its label. The text itself is kept.
+func dropLongMarkup(text, parseMode string, limit int) string {
+	if parseMode != ParseModeHTML && parseMode != ParseModeMarkdownV2 {
+		return text
+	}
+	m := scan(text, parseMode)
+	var b strings.Builder
+	// dropped holds the stack depth of every dropped HTML entity still open.
+	var dropped []int
+	changed := false
+	for i := 0; i < len(text); {
+		end := i + 1
+		for end < len(text) && !m.safe[end] {
+			end++
+		}
+		unit := text[i:end]
+		long := utf16Len(unit) > limit/2
+		switch {
+		case parseMode == ParseModeHTML && len(dropped) > 0 && strings.HasPrefix(unit, "</") &&
+			len(m.open(end)) <= dropped[len(dropped)-1]:
+			dropped = dropped[:len(dropped)-1]
+			changed = true
+		case parseMode == ParseModeHTML && long && unit[0] == '<' && len(m.open(end)) > len(m.open(i)):
+			dropped = append(dropped, len(m.open(i)))
+			changed = true
+		case parseMode == ParseModeMarkdownV2 && long && unit[0] == '[' && len(unit) > 1:
+			b.WriteString(markdownLinkLabel(unit))
+			changed = true
+		default:
+			b.WriteString(unit)
+		}
+		i = end
+	}
+	if !changed {
+		return text
+	}
+	return b.String()
+}
+
+// markdownLinkLabel returns the text between the brackets of a "[text](url)" link.
+func markdownLinkLabel(link string) string {
+	for j := 1; j < len(link); j++ {
+		switch link[j] {
+		case '\\':
+			j++
+		case ']':
+			return link[1:j]
+		}
+	}
+	return link
+}
+
+// scan records where text may be cut and which entities are open for the parse mode.
+func scan(text, parseMode string) *markup {
+	m := &markup{
+		safe:   make([]bool, len(text)+1),
+		stack:  make([]int, len(text)+1),
+		stacks: [][]entity{nil},
+	}
+	switch parseMode {
+	case ParseModeHTML:
+		m.scanHTML(text)
+	case ParseModeMarkdownV2:
+		m.scanMarkdownV2(text)
+	default:
+		for i := range m.safe {
+			m.safe[i] = i == len(text) || utf8.RuneStart(text[i])
+		}
+	}
+	return m
+}
+
+// mark records the state for bytes [from, to): only from is a cut point.
+func (m *markup) mark(from, to int, open []entity) {
+	id := len(m.stacks) - 1
+	if !sameEntities(m.stacks[id], open) {
+		m.stacks = append(m.stacks, append([]entity(nil), open...))
+		id++
+	}
+	for i := from; i < to; i++ {
+		m.safe[i] = i == from
+		m.stack[i] = id
+	}
+}
+
+func (m *markup) finish(n int, open []entity) {
+	m.mark(n, n+1, open)
+}
+
+func (m *markup) scanHTML(text string) {
+	var open []entity
+	for i := 0; i < len(text); {
+		end := i
+		switch text[i] {
+		case '<':
+			if j := strings.IndexByte(text[i:], '>'); j > 0 {
+				end = i + j + 1
+			}
+		case '&':
+			if j := strings.IndexByte(text[i:], ';'); j > 0 && j <= 10 {
+				end = i + j + 1
+			}
+		}
+		if end == i {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			end = i + size
+		}
+		m.mark(i, end, open)
+		if text[i] == '<' && end > i+1 {
+			open = htmlTag(open, text[i:end])
+		}
+		i = end
+	}
+	m.finish(len(text), open)
+}
+
+// htmlTag applies an opening or closing tag to the open entity stack.
+func htmlTag(open []entity, tag string) []entity {
+	body := strings.TrimSuffix(strings.TrimPrefix(tag, "<"), ">")
+	if name, ok := strings.CutPrefix(body, "/"); ok {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for k := len(open) - 1; k >= 0; k-- {
+			if open[k].close == "</"+name+">" {
+				return open[:k:k]
+			}
+		}
+		return open
+	}
+	if strings.HasSuffix(body, "/") {
+		return open
+	}
+	name, _, _ := strings.Cut(body, " ")
+	name = strings.ToLower(name)
+	return append(open[:len(open):len(open)], entity{open: tag, close: "</" + name + ">"})
+}
+
+// markdownMarkers are the MarkdownV2 entity delimiters, longest first.
+var markdownMarkers = []string{"```", "__", "||", "`", "*", "_", "~"}
+
+func (m *markup) scanMarkdownV2(text string) {
+	var open []entity
+	code := func() string {
+		if len(open) > 0 && strings.HasPrefix(open[len(open)-1].close, "`") {
+			return open[len(open)-1].close
+		}
+		return ""
+	}
+	for i := 0; i < len(text); {
+		end := i
+		marker := ""
+		switch {
+		case text[i] == '\\' && i+1 < len(text):
+			_, size := utf8.DecodeRuneInString(text[i+1:])
+			end = i + 1 + size
+		case code() != "":
+			if strings.HasPrefix(text[i:], code()) {
+				marker = code()
+			}
+		case text[i] == '[':
+			end = markdownLinkEnd(text, i)
+		default:
+			for _, mk := range markdownMarkers {
+				if strings.HasPrefix(text[i:], mk) {
+					marker = mk
+					break
+				}
+			}
+		}
+
+		switch {
+		case marker == "```" && code() == "":
+			// The language line belongs to the opening delimiter.
+			end = i + len(marker)
+			if j := strings.IndexByte(text[end:], '\n'); j >= 0 {
+				end += j + 1
+			}
+			m.mark(i, end, open)
+			open = append(open[:len(open):len(open)], entity{open: text[i:end], close: "```"})
+		case marker != "":
+			end = i + len(marker)
+			m.mark(i, end, open)
+			open = toggle(open, marker)
+		default:
+			if end == i {
+				_, size := utf8.DecodeRuneInString(text[i:])
+				end = i + size
+			}
+			m.mark(i, end, open)
+		}
+		i = end
+	}
+	m.finish(len(text), open)
+}
+
+// toggle closes marker when it is open and opens it otherwise.
+func toggle(open []entity, marker string) []entity {
+	for k := len(open) - 1; k >= 0; k-- {
+		if open[k].close == marker {
+			return open[:k:k]
+		}
+	}
+	return append(open[:len(open):len(open)], entity{open: marker, close: marker})
+}
+
+// markdownLinkEnd returns the end of an inline link "[text](url)" starting at i,
+// or the end of the bracket alone when it is not a link.
+func markdownLinkEnd(text string, i int) int {
+	for j := i + 1; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case ']':
+			if j+1 < len(text) && text[j+1] == '(' {
+				if k := strings.IndexByte(text[j+2:], ')'); k >= 0 {
+					return j + 2 + k + 1
+				}
+			}
+			return i + 1
+		}
+	}
+	return i + 1
+}
+
+func openers(open []entity) string {
+	var b strings.Builder
+	for _, e := range open {
+		b.WriteString(e.open)
+	}
+	return b.String()
+}
+
+func closers(open []entity) string {
+	var b strings.Builder
+	for k := len(open) - 1; k >= 0; k-- {
+		b.WriteString(open[k].close)
+	}
+	return b.String()
+}
+
+func sameEntities(a, b []entity) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge joins consecutive messages to the same chat with the same parse mode and run,
+// separated by a blank line, as long as the result fits in limit. Messages with a keyboard
+// or editing another message are left alone.
+func Merge(msgs []Message, limit int) []Message {
+	if limit <= 0 {
+		limit = MaxMessageLength
+	}
+	var out []Message
+	for _, msg := range msgs {
+		if n := len(out); n > 0 {
+			last := &out[n-1]
+			if last.ChatID == msg.ChatID && last.ParseMode == msg.ParseMode && last.RunID == msg.RunID &&
+				plain(*last) && plain(msg) && utf16Len(last.Text)+2+utf16Len(msg.Text) <= limit {
+				last.Text += "\n\n" + msg.Text
+				continue
+			}
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// plain reports whether msg is a new message without a keyboard.
+func plain(msg Message) bool {
+	return len(msg.Keyboard) == 0 && msg.EditMessageID == 0
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeUTF16Len(r)
+	}
+	return n
+}
+
+func runeUTF16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
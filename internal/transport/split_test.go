@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	longURL := "https://example.com/" + strings.Repeat("x", 30)
+	tests := []struct {
+		name      string
+		text      string
+		parseMode string
+		limit     int
+		want      []string
+	}{
+		{"fits", "one two", "", 7, []string{"one two"}},
+		{"paragraph first", "one two\nthree\n\nfour five", "", 16, []string{"one two\nthree", "four five"}},
+		{"line before space", "one two\nthree four", "", 12, []string{"one two", "three four"}},
+		{"space", "one two three", "", 9, []string{"one two", "three"}},
+		{"hard cut without spaces", "abcdefghij", "", 4, []string{"abcd", "efgh", "ij"}},
+		{"emoji count as two units", "😀😀😀", "", 4, []string{"😀😀", "😀"}},
+		{"emoji fit by units not bytes", "😀😀", "", 4, []string{"😀😀"}},
+
+		{"html reopens formatting", "<b>one two three</b>", ParseModeHTML, 16, []string{"<b>one two</b>", "<b>three</b>"}},
+		{"html nested formatting", "<b><i>one two</i></b>", ParseModeHTML, 18, []string{"<b><i>one</i></b>", "<b><i>two</i></b>"}},
+		{"html entity kept whole", "abc&amp;def", ParseModeHTML, 5, []string{"abc", "&amp;", "def"}},
+		{"html long link tag dropped", `<a href="` + longURL + `">link</a> and some more words here`, ParseModeHTML, 20,
+			[]string{"link and some more", "words here"}},
+
+		{"markdown reopens formatting", "*one two three*", ParseModeMarkdownV2, 12, []string{"*one two*", "*three*"}},
+		{"markdown escape kept whole", `ab\.cd`, ParseModeMarkdownV2, 3, []string{"ab", `\.c`, "d"}},
+		{"markdown inline code", "`a b c d`", ParseModeMarkdownV2, 6, []string{"`a b`", "`c d`"}},
+		{"markdown pre keeps language", "```go\nline1\nline2\nline3\n```", ParseModeMarkdownV2, 20,
+			[]string{"```go\nline1\nline2```", "```go\nline3\n```"}},
+		{"markdown long link keeps label", "[label](" + longURL + ") and more text here", ParseModeMarkdownV2, 20,
+			[]string{"label and more text", "here"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Split(tt.text, tt.parseMode, tt.limit)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Split(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			for _, part := range got {
+				if n := utf16Len(part); n > tt.limit {
+					t.Errorf("part %q is %d units, limit %d", part, n, tt.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitMessageKeyboardOnLastPart(t *testing.T) {
+	kb := [][]Button{{{Text: "ok", Data: "ok"}}}
+	got := SplitMessage(Message{ChatID: 1, Text: "one two", Keyboard: kb}, 4)
+	if len(got) != 2 || got[0].Keyboard != nil || len(got[1].Keyboard) != 1 {
+		t.Errorf("SplitMessage = %+v, want the keyboard on the last part only", got)
+	}
+
+	edit := Message{ChatID: 1, Text: "one two", EditMessageID: 7}
+	if got := SplitMessage(edit, 4); len(got) != 1 {
+		t.Errorf("SplitMessage(edit) = %+v, want it unsplit", got)
+	}
+}
+
+// recordingProducer records the texts it is asked to send.
+type recordingProducer struct{ texts []string }
+
+func (p *recordingProducer) Send(_ context.Context, msg Message) error {
+	p.texts = append(p.texts, msg.Text)
+	return nil
+}
+
+func TestSplitterSendsParts(t *testing.T) {
+	rec := &recordingProducer{}
+	text := strings.Repeat("word ", MaxMessageLength/5+10)
+	if err := NewSplitter(rec).Send(context.Background(), Message{ChatID: 1, Text: text}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(rec.texts) != 2 {
+		t.Fatalf("sent %d parts, want 2", len(rec.texts))
+	}
+	for _, part := range rec.texts {
+		if utf16Len(part) > MaxMessageLength {
+			t.Errorf("part of %d units exceeds the limit", utf16Len(part))
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	kb := [][]Button{{{Text: "ok", Data: "ok"}}}
+	tests := []struct {
+		name  string
+		msgs  []Message
+		limit int
+		want  []string
+	}{
+		{"joins up to the limit", []Message{{ChatID: 1, Text: "aaa"}, {ChatID: 1, Text: "bbb"}, {ChatID: 1, Text: "ccc"}}, 8,
+			[]string{"aaa\n\nbbb", "ccc"}},
+		{"limit counts emoji as two units", []Message{{ChatID: 1, Text: "😀😀"}, {ChatID: 1, Text: "😀"}}, 7,
+			[]string{"😀😀", "😀"}},
+		{"different chats", []Message{{ChatID: 1, Text: "a"}, {ChatID: 2, Text: "b"}}, 100, []string{"a", "b"}},
+		{"different parse modes", []Message{{ChatID: 1, Text: "a"}, {ChatID: 1, Text: "b", ParseMode: ParseModeHTML}}, 100,
+			[]string{"a", "b"}},
+		{"different runs", []Message{{ChatID: 1, Text: "a", RunID: 1}, {ChatID: 1, Text: "b", RunID: 2}}, 100, []string{"a", "b"}},
+		{"keyboard left alone", []Message{{ChatID: 1, Text: "a"}, {ChatID: 1, Text: "b", Keyboard: kb}}, 100, []string{"a", "b"}},
+		{"edit left alone", []Message{{ChatID: 1, Text: "a", EditMessageID: 3}, {ChatID: 1, Text: "b"}}, 100, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, m := range Merge(tt.msgs, tt.limit) {
+				got = append(got, m.Text)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Merge = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
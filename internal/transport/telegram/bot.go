@@ -2,9 +2,13 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"cron-weather/internal/config"
 	"cron-weather/internal/transport"
@@ -12,12 +16,25 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// Update modes of TgBotConfig.Mode.
+const (
+	ModePolling = "polling"
+	ModeWebhook = "webhook"
+)
+
+// allowedUpdates are the update types the bot asks Telegram for.
+var allowedUpdates = []string{"message", "channel_post", "callback_query"}
+
 // TelegramBot implements both Consumer and Producer using Telegram Bot API.
+// Updates come from long polling or, in webhook mode, from an HTTP server.
 type TelegramBot struct {
 	bot *tgbotapi.BotAPI
 	log *slog.Logger
 
 	updates tgbotapi.UpdatesChannel
+
+	// webhook is set in webhook mode.
+	webhook *webhook
 }
 
 // NewTelegramBot initializes Telegram bot client using provided config.
@@ -31,23 +48,51 @@ func NewTelegramBot(cfg *config.Config, log *slog.Logger) (*TelegramBot, error)
 	// Keep it opt-in via env TG_DEBUG=true.
 	bot.Debug = cfg.TgBot.Debug
 
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 30
-	u.AllowedUpdates = []string{"message", "channel_post"}
-	updates := bot.GetUpdatesChan(u)
-
-	return &TelegramBot{bot: bot, log: log, updates: updates}, nil
+	t := &TelegramBot{bot: bot, log: log}
+	switch mode := strings.ToLower(strings.TrimSpace(cfg.TgBot.Mode)); mode {
+	case "", ModePolling:
+		// getUpdates is refused while a webhook is registered, e.g. after switching modes.
+		if _, err := bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			return nil, fmt.Errorf("failed to delete tg webhook: %w", err)
+		}
+		u := tgbotapi.NewUpdate(0)
+		u.Timeout = 30
+		u.AllowedUpdates = allowedUpdates
+		t.updates = bot.GetUpdatesChan(u)
+	case ModeWebhook:
+		wh, err := newWebhook(bot, log, cfg.TgBot)
+		if err != nil {
+			return nil, err
+		}
+		t.webhook = wh
+		t.updates = wh.updates
+	default:
+		return nil, fmt.Errorf("unknown tg mode %q (use %s or %s)", mode, ModePolling, ModeWebhook)
+	}
+	return t, nil
 }
 
 // Get starts receiving incoming commands and returns a channel of parsed CronJob events.
+// In webhook mode it also registers the webhook and starts the HTTP server.
 func (t *TelegramBot) Get(ctx context.Context) (<-chan transport.CronJob, error) {
+	if t.webhook != nil {
+		if err := t.webhook.start(); err != nil {
+			return nil, err
+		}
+	}
+
 	out := make(chan transport.CronJob)
 
-	t.log.Info("telegram bot started", slog.String("username", t.bot.Self.UserName))
+	t.log.Info("telegram bot started",
+		slog.String("username", t.bot.Self.UserName),
+		slog.Bool("webhook", t.webhook != nil),
+	)
 
 	go func() {
 		defer close(out)
-		defer t.bot.StopReceivingUpdates()
+		if t.webhook == nil {
+			defer t.bot.StopReceivingUpdates()
+		}
 
 		for {
 			select {
@@ -61,7 +106,11 @@ func (t *TelegramBot) Get(ctx context.Context) (<-chan transport.CronJob, error)
 				if !ok {
 					continue
 				}
-				out <- job
+				select {
+				case out <- job:
+				case <-ctx.Done():
+					return
+				}
 			}
 		}
 	}()
@@ -69,17 +118,120 @@ func (t *TelegramBot) Get(ctx context.Context) (<-chan transport.CronJob, error)
 	return out, nil
 }
 
-// Send delivers a message to Telegram.
+// Close stops the webhook server and, unless TG_WEBHOOK_KEEP is set, deletes the webhook.
+// It does nothing in polling mode.
+func (t *TelegramBot) Close(ctx context.Context) error {
+	if t.webhook == nil {
+		return nil
+	}
+	return t.webhook.stop(ctx)
+}
+
+// Send delivers a message to Telegram. The text must fit the length limit; long messages are
+// split before they reach the bot, see transport.NewSplitter.
+// A message with EditMessageID replaces the text and keyboard of that message.
 func (t *TelegramBot) Send(ctx context.Context, msg transport.Message) error {
-	m := tgbotapi.NewMessage(msg.ChatID, msg.Text)
-	_, err := t.bot.Send(m)
-	if err != nil {
-		return fmt.Errorf("tg send: %w", err)
+	var c tgbotapi.Chattable
+	if msg.EditMessageID != 0 {
+		m := tgbotapi.NewEditMessageText(msg.ChatID, msg.EditMessageID, msg.Text)
+		m.ParseMode = msg.ParseMode
+		m.ReplyMarkup = keyboard(msg.Keyboard)
+		c = m
+	} else {
+		m := tgbotapi.NewMessage(msg.ChatID, msg.Text)
+		m.ParseMode = msg.ParseMode
+		if kb := keyboard(msg.Keyboard); kb != nil {
+			m.ReplyMarkup = *kb
+		}
+		c = m
+	}
+	if _, err := t.bot.Send(c); err != nil {
+		// Pressing a button that leaves the message as it was is not a failure.
+		if msg.EditMessageID != 0 && strings.Contains(err.Error(), "message is not modified") {
+			return nil
+		}
+		return sendError(err, t.bot.Token)
+	}
+	return nil
+}
+
+// AnswerCallback acknowledges a button press, optionally showing text as a notification.
+func (t *TelegramBot) AnswerCallback(ctx context.Context, callbackID, text string) error {
+	if _, err := t.bot.Request(tgbotapi.NewCallback(callbackID, text)); err != nil {
+		return fmt.Errorf("tg answer callback: %w", err)
+	}
+	return nil
+}
+
+// SetCommands registers the command menu with setMyCommands.
+func (t *TelegramBot) SetCommands(ctx context.Context, lang string, cmds []transport.BotCommand) error {
+	commands := make([]tgbotapi.BotCommand, 0, len(cmds))
+	for _, c := range cmds {
+		commands = append(commands, tgbotapi.BotCommand{Command: c.Name, Description: c.Description})
+	}
+	cfg := tgbotapi.NewSetMyCommandsWithScopeAndLanguage(tgbotapi.NewBotCommandScopeDefault(), lang, commands...)
+	if _, err := t.bot.Request(cfg); err != nil {
+		return fmt.Errorf("tg set commands: %w", err)
 	}
 	return nil
 }
 
+// keyboard converts transport buttons to an inline keyboard (nil when there are none).
+func keyboard(rows [][]transport.Button) *tgbotapi.InlineKeyboardMarkup {
+	if len(rows) == 0 {
+		return nil
+	}
+	kb := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: make([][]tgbotapi.InlineKeyboardButton, 0, len(rows))}
+	for _, row := range rows {
+		buttons := make([]tgbotapi.InlineKeyboardButton, 0, len(row))
+		for _, b := range row {
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(b.Text, b.Data))
+		}
+		kb.InlineKeyboard = append(kb.InlineKeyboard, buttons)
+	}
+	return &kb
+}
+
+// sendError classifies Bot API errors: 429 carries retry_after, other 4xx are permanent
+// (bad request, bot blocked, chat not found); network and 5xx errors stay transient.
+// Network errors (*url.Error) carry the request URL, which contains the bot token; it is masked.
+func sendError(err error, token string) error {
+	var ue *url.Error
+	if token != "" && errors.As(err, &ue) {
+		ue.URL = strings.ReplaceAll(ue.URL, token, "***")
+	}
+	wrapped := fmt.Errorf("tg send: %w", err)
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return &transport.SendError{Err: wrapped}
+	}
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests:
+		return &transport.SendError{Err: wrapped, RetryAfter: time.Duration(apiErr.RetryAfter) * time.Second}
+	case apiErr.Code >= 400 && apiErr.Code < 500:
+		return &transport.SendError{Err: wrapped, Permanent: true}
+	default:
+		return &transport.SendError{Err: wrapped}
+	}
+}
+
 func (t *TelegramBot) toCronJob(upd tgbotapi.Update) (transport.CronJob, bool) {
+	if cq := upd.CallbackQuery; cq != nil {
+		if cq.Message == nil {
+			// Buttons of inline-mode messages carry no chat.
+			return transport.CronJob{}, false
+		}
+		return transport.CronJob{
+			ChatID:  cq.Message.Chat.ID,
+			Command: "callback",
+			Callback: &transport.Callback{
+				ID:        cq.ID,
+				MessageID: cq.Message.MessageID,
+				Data:      cq.Data,
+			},
+		}, true
+	}
+
 	msg := upd.Message
 	if msg == nil {
 		msg = upd.ChannelPost
@@ -88,12 +240,28 @@ func (t *TelegramBot) toCronJob(upd tgbotapi.Update) (transport.CronJob, bool) {
 		return transport.CronJob{}, false
 	}
 
+	chatID := msg.Chat.ID
+
+	// A shared location (or venue) sets the subscription coordinates.
+	if msg.Location != nil {
+		args := fmt.Sprintf("%f %f", msg.Location.Latitude, msg.Location.Longitude)
+		return transport.CronJob{ChatID: chatID, Command: "set_location", Args: args}, true
+	}
+
 	if !msg.IsCommand() {
+		// Plain text answers interactive flows such as the /new wizard.
+		if text := strings.TrimSpace(msg.Text); text != "" {
+			return transport.CronJob{ChatID: chatID, Args: text}, true
+		}
+		return transport.CronJob{}, false
+	}
+
+	// In groups "/cmd@name" may be meant for another bot.
+	if _, to, ok := strings.Cut(msg.CommandWithAt(), "@"); ok && !strings.EqualFold(to, t.bot.Self.UserName) {
 		return transport.CronJob{}, false
 	}
 
 	cmd := strings.ToLower(msg.Command())
-	chatID := msg.Chat.ID
 
 	args := strings.TrimSpace(msg.CommandArguments())
 
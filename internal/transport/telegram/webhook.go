@@ -0,0 +1,150 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"cron-weather/internal/config"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// secretHeader carries the secret_token passed to setWebhook.
+	secretHeader = "X-Telegram-Bot-Api-Secret-Token"
+	// maxUpdateSize caps the request body of one update.
+	maxUpdateSize = 1 << 20
+)
+
+// webhook receives updates from Telegram over HTTP.
+type webhook struct {
+	bot    *tgbotapi.BotAPI
+	log    *slog.Logger
+	url    *url.URL
+	listen string
+	secret string
+	keep   bool
+
+	updates chan tgbotapi.Update
+	// done is closed on stop to release handlers waiting for a consumer.
+	done   chan struct{}
+	server *http.Server
+}
+
+func newWebhook(bot *tgbotapi.BotAPI, log *slog.Logger, cfg config.TgBotConfig) (*webhook, error) {
+	u, err := url.Parse(cfg.WebhookURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("tg webhook: TG_WEBHOOK_URL must be an https URL, got %q", cfg.WebhookURL)
+	}
+	if !validSecret(cfg.WebhookSecret) {
+		return nil, fmt.Errorf("tg webhook: TG_WEBHOOK_SECRET must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	w := &webhook{
+		bot:     bot,
+		log:     log,
+		url:     u,
+		listen:  cfg.WebhookListen,
+		secret:  cfg.WebhookSecret,
+		keep:    cfg.WebhookKeep,
+		updates: make(chan tgbotapi.Update),
+		done:    make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+path, w.handle)
+	w.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return w, nil
+}
+
+// start listens on the configured address and registers the webhook with Telegram.
+func (w *webhook) start() error {
+	ln, err := net.Listen("tcp", w.listen)
+	if err != nil {
+		return fmt.Errorf("tg webhook listen: %w", err)
+	}
+	go func() {
+		if err := w.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			w.log.Error("tg webhook server stopped", slog.Any("err", err))
+		}
+	}()
+
+	// WebhookConfig in the client library has no secret_token, so the request is built by hand.
+	params := tgbotapi.Params{"url": w.url.String(), "secret_token": w.secret}
+	if err := params.AddInterface("allowed_updates", allowedUpdates); err != nil {
+		return err
+	}
+	if _, err := w.bot.MakeRequest("setWebhook", params); err != nil {
+		_ = w.server.Close()
+		return fmt.Errorf("tg set webhook: %w", err)
+	}
+	w.log.Info("tg webhook registered", slog.String("url", w.url.Redacted()), slog.String("listen", w.listen))
+	return nil
+}
+
+// stop shuts the server down and deletes the webhook unless it is kept for other replicas.
+func (w *webhook) stop(ctx context.Context) error {
+	close(w.done)
+	var errs []error
+	if !w.keep {
+		if _, err := w.bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			errs = append(errs, fmt.Errorf("tg delete webhook: %w", err))
+		}
+	}
+	if err := w.server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("tg webhook shutdown: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// handle decodes one update and waits until it is consumed, so Telegram retries
+// updates that were not taken before shutdown.
+func (w *webhook) handle(rw http.ResponseWriter, r *http.Request) {
+	got := r.Header.Get(secretHeader)
+	if subtle.ConstantTimeCompare([]byte(got), []byte(w.secret)) != 1 {
+		w.log.Warn("tg webhook request with invalid secret", slog.String("remote", r.RemoteAddr))
+		http.Error(rw, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var upd tgbotapi.Update
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxUpdateSize)).Decode(&upd); err != nil {
+		w.log.Warn("tg webhook bad update", slog.Any("err", err))
+		http.Error(rw, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case w.updates <- upd:
+		rw.WriteHeader(http.StatusOK)
+	case <-w.done:
+		http.Error(rw, "shutting down", http.StatusServiceUnavailable)
+	case <-r.Context().Done():
+	}
+}
+
+// validSecret reports whether s is a valid setWebhook secret_token.
+func validSecret(s string) bool {
+	if len(s) == 0 || len(s) > 256 {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
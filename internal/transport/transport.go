@@ -1,26 +1,92 @@
 // Package transport defines message delivery interfaces and payload types.
 package transport
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // CronJob describes a scheduled job command received from a transport (Telegram).
+// Plain text messages have an empty Command and the text in Args.
 type CronJob struct {
 	ChatID  int64
 	Command string
 	Args    string
+	// Callback is set when the job comes from an inline keyboard button.
+	Callback *Callback
 }
 
+// Callback is an inline keyboard button press.
+type Callback struct {
+	// ID identifies the press for Answerer.AnswerCallback.
+	ID string
+	// MessageID is the message carrying the keyboard.
+	MessageID int
+	// Data is the callback data of the pressed button.
+	Data string
+}
+
+// Button is an inline keyboard button that sends Data back as a Callback.
+type Button struct {
+	Text string
+	Data string
+}
+
+// Telegram parse modes supported by Message.ParseMode. Empty means plain text.
+const (
+	ParseModeHTML       = "HTML"
+	ParseModeMarkdownV2 = "MarkdownV2"
+)
+
 // Message is an outgoing message to be delivered to a chat.
 type Message struct {
-	ChatID int64
-	Text   string
+	ChatID    int64
+	Text      string
+	ParseMode string
+	// RunID links a scheduled message to its runs row for delivery reporting (0 means none).
+	RunID int64
+	// Keyboard is an optional inline keyboard, one slice per row.
+	Keyboard [][]Button
+	// EditMessageID replaces the text and keyboard of an earlier message instead of sending a new one.
+	EditMessageID int
+}
+
+// SendError is a delivery error that tells the caller whether and when to retry.
+type SendError struct {
+	Err error
+	// RetryAfter is the wait requested by the transport (e.g. Telegram 429 retry_after).
+	RetryAfter time.Duration
+	// Permanent means retrying cannot succeed (e.g. the bot was blocked or the chat does not exist).
+	Permanent bool
 }
 
+func (e *SendError) Error() string { return e.Err.Error() }
+
+func (e *SendError) Unwrap() error { return e.Err }
+
 // Consumer provides incoming updates (e.g. Telegram updates) to the application.
 type Consumer interface {
 	Get(ctx context.Context) (<-chan CronJob, error)
 }
 
+// Answerer acknowledges callbacks, e.g. to stop the loading indicator of a pressed button.
+// Consumers that produce callbacks may implement it.
+type Answerer interface {
+	AnswerCallback(ctx context.Context, callbackID, text string) error
+}
+
+// BotCommand is an entry of the command menu a transport shows to users.
+type BotCommand struct {
+	Name        string
+	Description string
+}
+
+// CommandSetter publishes the command menu. Consumers with such a menu may implement it.
+type CommandSetter interface {
+	// SetCommands replaces the menu shown to users with language lang; an empty lang sets the default.
+	SetCommands(ctx context.Context, lang string, cmds []BotCommand) error
+}
+
 // Producer delivers outgoing messages to a target transport.
 type Producer interface {
 	Send(ctx context.Context, msg Message) error
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// redacted replaces secret values.
+const redacted = "***"
+
+// minSecretLen avoids redacting short values that would mangle unrelated text.
+const minSecretLen = 6
+
+// secretKeys are attribute keys whose values are always hidden.
+var secretKeys = []string{"token", "api_key", "apikey", "appid", "password", "secret"}
+
+// Redactor hides secret values (API keys, bot tokens) in strings and slog attributes.
+type Redactor struct {
+	secrets []string
+}
+
+// NewRedactor returns a redactor for the given secrets; empty and short values are ignored.
+func NewRedactor(secrets ...string) *Redactor {
+	r := &Redactor{}
+	for _, s := range secrets {
+		s = strings.TrimSpace(s)
+		if len(s) >= minSecretLen {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	return r
+}
+
+// Redact replaces every secret in s.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// ReplaceAttr is a slog.HandlerOptions.ReplaceAttr hook that redacts secrets in attribute values
+// and hides attributes with secret-looking keys. Group members are redacted one by one.
+func (r *Redactor) ReplaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if r == nil {
+		return a
+	}
+	key := strings.ToLower(a.Key)
+	for _, k := range secretKeys {
+		if strings.Contains(key, k) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.Redact(v.String()))
+	case slog.KindAny:
+		var s string
+		if err, ok := v.Any().(error); ok {
+			s = err.Error()
+		} else {
+			s = fmt.Sprint(v.Any())
+		}
+		if red := r.Redact(s); red != s {
+			return slog.String(a.Key, red)
+		}
+	case slog.KindGroup:
+		members := v.Group()
+		out := make([]slog.Attr, 0, len(members))
+		for _, m := range members {
+			out = append(out, r.ReplaceAttr(nil, m))
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(out...)}
+	}
+	return a
+}
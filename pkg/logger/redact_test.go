@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// loggable resolves to a group holding a URL.
+type loggable struct{ url string }
+
+func (l loggable) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("url", l.url), slog.Int("status", 401))
+}
+
+func TestRedactorReplaceAttr(t *testing.T) {
+	const secret = "123456:bot-secret"
+	r := NewRedactor(secret, "short")
+	url := "https://api.telegram.org/bot" + secret + "/getMe"
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: r.ReplaceAttr}))
+	log.Info("request",
+		slog.String("url", url),
+		slog.Any("err", errors.New("Post "+url+": timeout")),
+		slog.String("api_key", "plain"),
+		slog.Group("req", slog.String("url", url), slog.Group("inner", slog.String("token", "x"))),
+		slog.Any("resp", loggable{url: url}),
+	)
+
+	out := buf.String()
+	if strings.Contains(out, secret) || strings.Contains(out, "plain") {
+		t.Errorf("secret leaked: %s", out)
+	}
+	for _, want := range []string{"req.url=https://api.telegram.org/bot***/getMe", "req.inner.token=***", "resp.status=401", "err=\"Post "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %s lacks %q", out, want)
+		}
+	}
+}
+
+// TestRedactorReplaceAttrGroup calls the hook with a whole group, as callers other than the
+// standard handlers may do.
+func TestRedactorReplaceAttrGroup(t *testing.T) {
+	r := NewRedactor("secret-value")
+	a := r.ReplaceAttr(nil, slog.Group("g", slog.String("msg", "has secret-value"), slog.String("password", "p"), slog.Int("n", 1)))
+
+	got := map[string]string{}
+	for _, m := range a.Value.Group() {
+		got[m.Key] = m.Value.String()
+	}
+	if got["msg"] != "has ***" || got["password"] != "***" || got["n"] != "1" {
+		t.Errorf("group members = %v", got)
+	}
+}